@@ -3,20 +3,394 @@ package component
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/nioliu/commons/log"
 	"github.com/nioliu/protocols/httpproto"
 	"go.uber.org/zap"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-const dataServiceUrl = "http://data-service:8080"
+var ErrContentCheckCircuitOpen = errors.New("content check circuit breaker is open")
+
+const (
+	defaultDataServiceUrl      = "http://data-service:8080"
+	checkContentPath           = "/v1/data/content/check"
+	defaultCheckContentTimeout = 5 * time.Second
+	defaultCheckAttempts       = 3
+	defaultCheckRetryBase      = 100 * time.Millisecond
+	defaultCheckRetryMax       = time.Second
+	defaultBreakerThreshold    = 5
+	defaultBreakerOpenTimeout  = 30 * time.Second
+	defaultCheckConcurrency    = 8
+	checkRetryJitterFraction   = 0.2
+)
+
+// ContentCheckStatusError 数据服务返回了非200的状态码
+type ContentCheckStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ContentCheckStatusError) Error() string {
+	return fmt.Sprintf("request failed with status code: %d", e.StatusCode)
+}
+
+// ContentCheckFallback 熔断时代替数据服务给出结果，比如使用本地的敏感词过滤
+type ContentCheckFallback func(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error)
+
+// ContentChecker 调用数据服务检查内容，连接错误和502/503/504时重试，连续失败后熔断
+type ContentChecker struct {
+	baseURL     string
+	client      *http.Client
+	timeout     time.Duration
+	header      http.Header
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	breaker     *circuitBreaker
+	fallback    ContentCheckFallback // 为空时熔断期间返回ErrContentCheckCircuitOpen
+	concurrency int
+	cache       ContentCheckCache
+	cacheTTL    time.Duration
+	group       singleflight
+	cacheStats  struct {
+		hits, misses, shared atomic.Int64
+	}
+}
+
+type ContentCheckerOption func(c *ContentChecker)
+
+// WithCheckerBaseURL 数据服务地址，默认 http://data-service:8080
+func WithCheckerBaseURL(baseURL string) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithCheckerHTTPClient 使用自定义的http.Client，比如测试时使用httptest.Server.Client()
+func WithCheckerHTTPClient(client *http.Client) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.client = client
+	}
+}
+
+// WithCheckerTransport 只替换Transport，需要在 WithCheckerHTTPClient 之后设置
+func WithCheckerTransport(transport http.RoundTripper) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		client := *c.client
+		client.Transport = transport
+		c.client = &client
+	}
+}
+
+// WithCheckerTimeout 每次请求的超时时间，重试时单独计算，默认5s，小于等于0时只使用ctx的超时
+func WithCheckerTimeout(timeout time.Duration) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.timeout = timeout
+	}
+}
+
+// WithCheckerHeader 每次请求都带上的请求头，比如鉴权信息
+func WithCheckerHeader(key, value string) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithCheckerRetry 最多请求几次，第n次重试前等待 base*2^(n-1)，不超过max，并加上20%的随机抖动；
+// 默认3次、100ms、1s，maxAttempts为1时不重试
+func WithCheckerRetry(maxAttempts int, base, max time.Duration) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.maxAttempts = maxAttempts
+		c.retryBase = base
+		c.retryMax = max
+	}
+}
+
+// WithCheckerCircuitBreaker 连续threshold次调用失败后熔断openTimeout，之后放行一个请求探测；
+// 默认5次、30s，threshold小于等于0时不熔断
+func WithCheckerCircuitBreaker(threshold int, openTimeout time.Duration) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		if threshold <= 0 {
+			c.breaker = nil
+			return
+		}
+		c.breaker = newCircuitBreaker(threshold, openTimeout)
+	}
+}
+
+// WithCheckerFailOpen 熔断期间使用fallback的结果放行，默认fail-closed，返回ErrContentCheckCircuitOpen
+func WithCheckerFailOpen(fallback ContentCheckFallback) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.fallback = fallback
+	}
+}
+
+// WithCheckerFailClosed 熔断期间返回ErrContentCheckCircuitOpen
+func WithCheckerFailClosed() ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.fallback = nil
+	}
+}
+
+// WithCheckerConcurrency CheckBatch 同时进行的请求数，默认8
+func WithCheckerConcurrency(n int) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		c.concurrency = n
+	}
+}
+
+// WithCheckerCache 按请求的哈希缓存检查结果ttl，默认不缓存，ttl小于等于0时为10分钟
+func WithCheckerCache(cache ContentCheckCache, ttl time.Duration) ContentCheckerOption {
+	return func(c *ContentChecker) {
+		if ttl <= 0 {
+			ttl = defaultContentCacheTTL
+		}
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+func NewContentChecker(opts ...ContentCheckerOption) *ContentChecker {
+	c := &ContentChecker{
+		baseURL:     defaultDataServiceUrl,
+		client:      &http.Client{},
+		timeout:     defaultCheckContentTimeout,
+		header:      http.Header{},
+		maxAttempts: defaultCheckAttempts,
+		retryBase:   defaultCheckRetryBase,
+		retryMax:    defaultCheckRetryMax,
+		breaker:     newCircuitBreaker(defaultBreakerThreshold, defaultBreakerOpenTimeout),
+		concurrency: defaultCheckConcurrency,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// DefaultContentChecker CheckContent 使用的默认实例
+var DefaultContentChecker = NewContentChecker()
 
 func CheckContent(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+	return DefaultContentChecker.Check(ctx, req)
+}
+
+// CheckContentBatch 使用默认实例批量检查
+func CheckContentBatch(ctx context.Context, reqs []*httpproto.CheckContentReq) []ContentCheckResult {
+	return DefaultContentChecker.CheckBatch(ctx, reqs)
+}
+
+// ContentCheckResult 批量检查中单个请求的结果
+type ContentCheckResult struct {
+	Rsp *httpproto.CheckContentRsp
+	Err error
+}
+
+// CheckBatch 并发检查多条内容，结果与reqs顺序一致，单条失败不影响其他；
+// ctx结束后还没有开始的请求返回ctx.Err()
+func (c *ContentChecker) CheckBatch(ctx context.Context, reqs []*httpproto.CheckContentReq) []ContentCheckResult {
+	results := make([]ContentCheckResult, len(reqs))
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(reqs); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+		wg.Add(1)
+		go func(i int, req *httpproto.CheckContentReq) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i].Rsp, results[i].Err = c.Check(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+	return results
+}
+
+// Check 设置了缓存时相同的请求直接返回缓存的结果，同时进行的相同请求只会请求一次数据服务
+func (c *ContentChecker) Check(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+	if c.cache == nil {
+		rsp, _, err := c.check(ctx, req)
+		return rsp, err
+	}
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(reqBytes)
+	key := hex.EncodeToString(sum[:])
+	raw, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		log.WarnWithCtxFields(ctx, "get content check cache failed", zap.Error(err))
+	}
+	if ok {
+		c.cacheStats.hits.Add(1)
+		return decodeCheckContentRsp(raw)
+	}
+
+	c.cacheStats.misses.Add(1)
+	raw, shared, err := c.group.do(ctx, key, func() ([]byte, error) {
+		// 共用的请求不随发起者取消，否则等待同一结果的其他调用会一起失败
+		ctx, cancel := c.detach(ctx)
+		defer cancel()
+		rsp, fromService, err := c.check(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(rsp)
+		if err != nil {
+			return nil, err
+		}
+		// 熔断时降级的结果不缓存
+		if fromService {
+			if err = c.cache.Set(ctx, key, raw, c.cacheTTL); err != nil {
+				log.WarnWithCtxFields(ctx, "set content check cache failed", zap.Error(err))
+			}
+		}
+		return raw, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		c.cacheStats.shared.Add(1)
+	}
+	return decodeCheckContentRsp(raw)
+}
+
+// detach 返回不随ctx取消的上下文，超时为所有重试加起来的最长时间，没有设置超时时不限制
+func (c *ContentChecker) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	attempts := time.Duration(max(c.maxAttempts, 1))
+	backoff := time.Duration(float64(c.retryMax) * (1 + checkRetryJitterFraction))
+	return context.WithTimeout(ctx, c.timeout*attempts+backoff*(attempts-1))
+}
+
+// CacheStats 缓存命中统计，没有设置缓存时都为0
+func (c *ContentChecker) CacheStats() ContentCacheStats {
+	return ContentCacheStats{
+		Hits:   c.cacheStats.hits.Load(),
+		Misses: c.cacheStats.misses.Load(),
+		Shared: c.cacheStats.shared.Load(),
+	}
+}
+
+// decodeCheckContentRsp 每个调用方得到单独的一份结果
+func decodeCheckContentRsp(raw []byte) (*httpproto.CheckContentRsp, error) {
+	rsp := new(httpproto.CheckContentRsp)
+	if err := json.Unmarshal(raw, rsp); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+// check 带重试和熔断地请求数据服务，fromService为false时结果来自熔断降级
+func (c *ContentChecker) check(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, bool, error) {
+	if !c.breaker.allow() {
+		if c.fallback != nil {
+			rsp, err := c.fallback(ctx, req)
+			return rsp, false, err
+		}
+		return nil, false, ErrContentCheckCircuitOpen
+	}
+
+	var rsp *httpproto.CheckContentRsp
+	var err error
+	for attempt := 1; ; attempt++ {
+		rsp, err = c.do(ctx, req)
+		if err == nil || attempt >= c.maxAttempts || !isRetryableCheckError(ctx, err) {
+			break
+		}
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	switch {
+	case err == nil:
+		c.breaker.record(false)
+	case ctx.Err() != nil:
+		c.breaker.release()
+	default:
+		// 4xx说明服务本身正常，不计入熔断
+		var statusErr *ContentCheckStatusError
+		c.breaker.record(!errors.As(err, &statusErr) || statusErr.StatusCode >= http.StatusInternalServerError)
+	}
+	return rsp, true, err
+}
+
+// isRetryableCheckError 连接错误、单次请求超时以及502/503/504可以重试，调用方取消时不再重试
+func isRetryableCheckError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *ContentCheckStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// http.Client 返回的连接错误和超时都是 *url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+func (c *ContentChecker) backoff(attempt int) time.Duration {
+	d := c.retryBase
+	for i := 1; i < attempt && d < c.retryMax; i++ {
+		d *= 2
+	}
+	if d > c.retryMax {
+		d = c.retryMax
+	}
+	return d + time.Duration(rand.Float64()*checkRetryJitterFraction*float64(d))
+}
+
+// do 请求一次数据服务
+func (c *ContentChecker) do(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
 
-	baseURL := dataServiceUrl + "/v1/data/content/check"
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		log.ErrorWithCtxFields(ctx, "marshal request failed", zap.Error(err))
@@ -24,20 +398,23 @@ func CheckContent(ctx context.Context, req *httpproto.CheckContentReq) (*httppro
 	}
 
 	// 创建请求
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(reqBytes))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+checkContentPath, bytes.NewReader(reqBytes))
 	if err != nil {
 		log.ErrorWithCtxFields(ctx, "create request failed", zap.Error(err))
-		return nil, fmt.Errorf("create request failed: " + err.Error())
+		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
 	// 设置请求头
+	for k, v := range c.header {
+		request.Header[k] = append([]string(nil), v...)
+	}
 	request.Header.Set("Content-Type", "application/json")
 
 	// 发送请求
-	rsp, err := http.DefaultClient.Do(request)
+	rsp, err := c.client.Do(request)
 	if err != nil {
 		log.ErrorWithCtxFields(ctx, "do request failed", zap.Error(err))
-		return nil, fmt.Errorf("do request failed: " + err.Error())
+		return nil, fmt.Errorf("do request failed: %w", err)
 	}
 	defer rsp.Body.Close()
 
@@ -47,14 +424,14 @@ func CheckContent(ctx context.Context, req *httpproto.CheckContentReq) (*httppro
 		log.ErrorWithCtxFields(ctx, "request failed with non-200 status code",
 			zap.Int("status_code", rsp.StatusCode),
 			zap.String("response_body", string(body)))
-		return nil, fmt.Errorf(fmt.Sprintf("request failed with status code: %d", rsp.StatusCode))
+		return nil, &ContentCheckStatusError{StatusCode: rsp.StatusCode, Body: string(body)}
 	}
 
 	// 读取响应
 	h := new(httpproto.CheckContentRsp)
 	if err = json.NewDecoder(rsp.Body).Decode(h); err != nil {
 		log.ErrorWithCtxFields(ctx, "decode response body failed", zap.Error(err))
-		return nil, fmt.Errorf("decode response body failed: " + err.Error())
+		return nil, fmt.Errorf("decode response body failed: %w", err)
 	}
 
 	return h, nil
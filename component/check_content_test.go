@@ -0,0 +1,197 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nioliu/protocols/httpproto"
+)
+
+func TestContentChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != checkContentPath || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Slow") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL+"/"), WithCheckerHTTPClient(server.Client()),
+		WithCheckerHeader("Authorization", "Bearer token"))
+	if _, err := checker.Check(context.Background(), &httpproto.CheckContentReq{}); err != nil {
+		t.Fatal(err)
+	}
+
+	unauthorized := NewContentChecker(WithCheckerBaseURL(server.URL))
+	if _, err := unauthorized.Check(context.Background(), &httpproto.CheckContentReq{}); err == nil {
+		t.Fatal("expected error for non-200 status")
+	}
+
+	slow := NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerTimeout(50*time.Millisecond),
+		WithCheckerHeader("Authorization", "Bearer token"), WithCheckerHeader("X-Slow", "1"))
+	start := time.Now()
+	if _, err := slow.Check(context.Background(), &httpproto.CheckContentReq{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("timeout not applied")
+	}
+}
+
+// flakyDataService 前failures次返回status，之后正常返回
+func flakyDataService(t *testing.T, status int, failures int32) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestContentCheckerRetry(t *testing.T) {
+	ctx := context.Background()
+	server, calls := flakyDataService(t, http.StatusServiceUnavailable, 2)
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerRetry(3, time.Millisecond, 10*time.Millisecond))
+	if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected 3 calls, got %d", n)
+	}
+
+	// 500不是临时错误，不重试
+	server, calls = flakyDataService(t, http.StatusInternalServerError, 1)
+	checker = NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerRetry(3, time.Millisecond, 10*time.Millisecond))
+	var statusErr *ContentCheckStatusError
+	if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+
+	// 连接错误重试后返回最后一次的错误
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	checker = NewContentChecker(WithCheckerBaseURL(closed.URL), WithCheckerRetry(2, time.Millisecond, time.Millisecond))
+	if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err == nil {
+		t.Fatal("expected connection error")
+	}
+}
+
+func TestContentCheckerCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	server, calls := flakyDataService(t, http.StatusBadGateway, 2)
+	opts := []ContentCheckerOption{WithCheckerBaseURL(server.URL), WithCheckerRetry(1, 0, 0),
+		WithCheckerCircuitBreaker(2, 100*time.Millisecond)}
+	checker := NewContentChecker(opts...)
+	for i := 0; i < 2; i++ {
+		if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	// 熔断后不再请求数据服务
+	if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); !errors.Is(err, ErrContentCheckCircuitOpen) {
+		t.Fatalf("expected circuit open, got %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 calls, got %d", n)
+	}
+
+	// 超时后放行探测请求，成功后恢复
+	time.Sleep(120 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// fail-open 熔断期间使用fallback
+	server, _ = flakyDataService(t, http.StatusServiceUnavailable, 100)
+	fallback := &httpproto.CheckContentRsp{}
+	checker = NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerRetry(1, 0, 0),
+		WithCheckerCircuitBreaker(1, time.Minute),
+		WithCheckerFailOpen(func(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+			return fallback, nil
+		}))
+	if _, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err == nil {
+		t.Fatal("expected error before breaker opens")
+	}
+	if rsp, err := checker.Check(ctx, &httpproto.CheckContentReq{}); err != nil || rsp != fallback {
+		t.Fatalf("expected fallback result, got %v %v", rsp, err)
+	}
+}
+
+func newCheckReq(content string) *httpproto.CheckContentReq {
+	return &httpproto.CheckContentReq{Content: content}
+}
+
+func TestContentCheckerBatch(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		body, _ := io.ReadAll(r.Body)
+		// 奇数编号的内容返回400
+		if strings.Contains(string(body), "odd") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerConcurrency(3))
+	reqs := make([]*httpproto.CheckContentReq, 10)
+	for i := range reqs {
+		content := fmt.Sprintf("even-%d", i)
+		if i%2 == 1 {
+			content = fmt.Sprintf("odd-%d", i)
+		}
+		reqs[i] = newCheckReq(content)
+	}
+	results := checker.CheckBatch(context.Background(), reqs)
+	for i, r := range results {
+		if (i%2 == 1) != (r.Err != nil) || (r.Err == nil) != (r.Rsp != nil) {
+			t.Fatalf("unexpected result %d: %+v", i, r)
+		}
+	}
+	if n := maxInflight.Load(); n > 3 {
+		t.Fatalf("concurrency limit exceeded: %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, r := range checker.CheckBatch(ctx, reqs) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("expected canceled for %d, got %v", i, r.Err)
+		}
+	}
+}
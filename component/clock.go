@@ -0,0 +1,213 @@
+package component
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时钟，测试中可以替换成可控的时钟来模拟回拨
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+// RollbackStrategy 发生时钟回拨时的处理策略
+type RollbackStrategy int
+
+const (
+	RollbackWait   RollbackStrategy = iota // 阻塞等待时钟追上，超过最大等待时间返回错误
+	RollbackBorrow                         // 沿用上一次的时间戳继续分配，序列号用完后借用未来的毫秒，最多领先时钟MaxBorrow
+	RollbackFail                           // 直接返回 *ClockRollbackError
+)
+
+func (s RollbackStrategy) String() string {
+	switch s {
+	case RollbackWait:
+		return "wait"
+	case RollbackBorrow:
+		return "borrow"
+	case RollbackFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	defaultRollbackMaxWait   = 100 * time.Millisecond
+	defaultRollbackMaxBorrow = time.Second
+)
+
+// RollbackPolicy 时钟回拨策略
+type RollbackPolicy struct {
+	Strategy  RollbackStrategy
+	MaxWait   time.Duration // RollbackWait 最长等待时间，默认100ms
+	MaxBorrow time.Duration // RollbackBorrow 逻辑时间最多领先时钟多久，默认1s
+}
+
+// DefaultRollbackPolicy 默认等待时钟追上
+var DefaultRollbackPolicy = RollbackPolicy{Strategy: RollbackWait}
+
+// ClockRollbackError 时钟回拨且无法按策略处理时返回，errors.Is(err, ErrClockMovedBackwards) 成立
+type ClockRollbackError struct {
+	Strategy RollbackStrategy
+	Behind   time.Duration // 时钟落后于上一次时间戳多久
+}
+
+func (e *ClockRollbackError) Error() string {
+	return fmt.Sprintf("%s: clock is %s behind, strategy %s", ErrClockMovedBackwards.Error(), e.Behind, e.Strategy)
+}
+
+func (e *ClockRollbackError) Unwrap() error {
+	return ErrClockMovedBackwards
+}
+
+// RollbackStats 各个策略被触发的次数
+type RollbackStats struct {
+	Rollbacks uint64 `json:"rollbacks"` // 检测到时钟回拨的次数
+	Waits     uint64 `json:"waits"`     // 等待时钟追上的次数
+	Borrows   uint64 `json:"borrows"`   // 借用逻辑时间戳的次数
+	Failures  uint64 `json:"failures"`  // 返回错误的次数
+}
+
+// rollbackGuard 按策略处理时钟回拨，时间戳单位为毫秒，基准由调用方决定
+type rollbackGuard struct {
+	policy RollbackPolicy
+
+	rollbacks atomic.Uint64
+	waits     atomic.Uint64
+	borrows   atomic.Uint64
+	failures  atomic.Uint64
+}
+
+func newRollbackGuard(policy RollbackPolicy) *rollbackGuard {
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = defaultRollbackMaxWait
+	}
+	if policy.MaxBorrow <= 0 {
+		policy.MaxBorrow = defaultRollbackMaxBorrow
+	}
+	return &rollbackGuard{policy: policy}
+}
+
+func (r *rollbackGuard) stats() RollbackStats {
+	return RollbackStats{
+		Rollbacks: r.rollbacks.Load(),
+		Waits:     r.waits.Load(),
+		Borrows:   r.borrows.Load(),
+		Failures:  r.failures.Load(),
+	}
+}
+
+func (r *rollbackGuard) fail(now, last int64) error {
+	r.failures.Add(1)
+	return &ClockRollbackError{
+		Strategy: r.policy.Strategy,
+		Behind:   time.Duration(last-now) * time.Millisecond,
+	}
+}
+
+// resolve 处理now < last的情况，返回可以使用的时间戳；借用时返回last，调用方应沿用last继续分配序列号
+func (r *rollbackGuard) resolve(now, last int64, read func() (int64, error)) (int64, error) {
+	r.rollbacks.Add(1)
+	switch r.policy.Strategy {
+	case RollbackFail:
+		return 0, r.fail(now, last)
+	case RollbackBorrow:
+		if last-now > r.policy.MaxBorrow.Milliseconds() {
+			return 0, r.fail(now, last)
+		}
+		r.borrows.Add(1)
+		return last, nil
+	default:
+		r.waits.Add(1)
+		return r.wait(now, last, read)
+	}
+}
+
+// advance 序列号用尽时返回严格大于last的时间戳，期间时钟落后于last时按策略处理
+func (r *rollbackGuard) advance(last int64, read func() (int64, error)) (int64, error) {
+	for {
+		now, err := read()
+		if err != nil {
+			return 0, err
+		}
+		if now > last {
+			return now, nil
+		}
+		if now == last {
+			time.Sleep(time.Millisecond / 4)
+			continue
+		}
+
+		r.rollbacks.Add(1)
+		switch r.policy.Strategy {
+		case RollbackFail:
+			return 0, r.fail(now, last)
+		case RollbackBorrow:
+			if last+1-now > r.policy.MaxBorrow.Milliseconds() {
+				return 0, r.fail(now, last)
+			}
+			r.borrows.Add(1)
+			return last + 1, nil
+		default:
+			r.waits.Add(1)
+			if _, err = r.wait(now, last, read); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+// wait 阻塞直到时钟追上target，超过MaxWait返回错误
+func (r *rollbackGuard) wait(now, target int64, read func() (int64, error)) (int64, error) {
+	if time.Duration(target-now)*time.Millisecond > r.policy.MaxWait {
+		return 0, r.fail(now, target)
+	}
+	start := time.Now()
+	var err error
+	for now < target {
+		if time.Since(start) > r.policy.MaxWait {
+			return 0, r.fail(now, target)
+		}
+		time.Sleep(time.Duration(target-now) * time.Millisecond)
+		if now, err = read(); err != nil {
+			return 0, err
+		}
+	}
+	return now, nil
+}
+
+// 字符串ID共用的回拨处理
+var (
+	legacyRollbackLock  = sync.RWMutex{}
+	legacyRollbackGuard = newRollbackGuard(DefaultRollbackPolicy)
+)
+
+// SetDefaultRollbackPolicy 设置 CreateSnowflakeId 等字符串ID函数的时钟回拨策略
+func SetDefaultRollbackPolicy(policy RollbackPolicy) {
+	legacyRollbackLock.Lock()
+	defer legacyRollbackLock.Unlock()
+	legacyRollbackGuard = newRollbackGuard(policy)
+}
+
+// DefaultRollbackStats 字符串ID函数的时钟回拨统计
+func DefaultRollbackStats() RollbackStats {
+	return getLegacyRollbackGuard().stats()
+}
+
+func getLegacyRollbackGuard() *rollbackGuard {
+	legacyRollbackLock.RLock()
+	defer legacyRollbackLock.RUnlock()
+	return legacyRollbackGuard
+}
@@ -0,0 +1,129 @@
+package component
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 可以手动拨动的时钟
+type manualClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: DefaultEpoch.Add(time.Hour)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Add(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRollbackFail(t *testing.T) {
+	clock := newManualClock()
+	g, err := NewGenerator(1, WithClock(clock), WithRollbackPolicy(RollbackPolicy{Strategy: RollbackFail}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(-5 * time.Millisecond)
+	_, err = g.NextId()
+	rollbackErr := &ClockRollbackError{}
+	if !errors.As(err, &rollbackErr) || !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("expected ClockRollbackError, got %v", err)
+	}
+	if rollbackErr.Behind != 5*time.Millisecond {
+		t.Fatalf("unexpected behind %s", rollbackErr.Behind)
+	}
+	if stats := g.RollbackStats(); stats.Rollbacks != 1 || stats.Failures != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRollbackWait(t *testing.T) {
+	clock := newManualClock()
+	g, err := NewGenerator(1, WithClock(clock),
+		WithRollbackPolicy(RollbackPolicy{Strategy: RollbackWait, MaxWait: time.Second}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 回拨3ms，10ms后时钟恢复
+	clock.Add(-3 * time.Millisecond)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clock.Add(3 * time.Millisecond)
+	}()
+	second, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second <= first {
+		t.Fatalf("id %d not greater than previous %d", second, first)
+	}
+	if stats := g.RollbackStats(); stats.Waits != 1 || stats.Failures != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// 回拨超过最大等待时间直接失败
+	clock.Add(-2 * time.Second)
+	if _, err = g.NextId(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("expected ErrClockMovedBackwards, got %v", err)
+	}
+}
+
+func TestRollbackBorrow(t *testing.T) {
+	clock := newManualClock()
+	layout := Layout{TimestampBits: 41, WorkerBits: 10, SequenceBits: 2}
+	g, err := NewGenerator(1, WithClock(clock), WithLayout(layout),
+		WithRollbackPolicy(RollbackPolicy{Strategy: RollbackBorrow, MaxBorrow: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 回拨5ms后继续生成，借用逻辑时间戳，序列号用完后借用未来的毫秒
+	clock.Add(-5 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		id, err := g.NextId()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not greater than previous %d", id, last)
+		}
+		last = id
+	}
+	if stats := g.RollbackStats(); stats.Borrows == 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// 超过最大借用范围
+	var borrowErr error
+	for i := 0; i < 100 && borrowErr == nil; i++ {
+		_, borrowErr = g.NextId()
+	}
+	if !errors.Is(borrowErr, ErrClockMovedBackwards) {
+		t.Fatalf("expected ErrClockMovedBackwards, got %v", borrowErr)
+	}
+}
@@ -0,0 +1,164 @@
+package component
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultContentCacheTTL    = 10 * time.Minute
+	defaultContentCachePrefix = "content_check:"
+)
+
+// ContentCheckCache 缓存内容检查的结果，键为请求的哈希，值为返回结果的JSON
+type ContentCheckCache interface {
+	// Get 不存在或者过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// ContentCacheStats 缓存命中统计
+type ContentCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Shared int64 `json:"shared"` // 未命中但是和同时进行的相同请求共用了结果
+}
+
+// LRUContentCheckCache 进程内的LRU缓存，超过容量时淘汰最久没有使用的结果
+type LRUContentCheckCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type lruContentEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+func NewLRUContentCheckCache(capacity int) *LRUContentCheckCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRUContentCheckCache{capacity: capacity, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *LRUContentCheckCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*lruContentEntry)
+	if !time.Now().Before(entry.expireAt) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (c *LRUContentCheckCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruContentEntry)
+		entry.value, entry.expireAt = value, time.Now().Add(ttl)
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(&lruContentEntry{key: key, value: value, expireAt: time.Now().Add(ttl)})
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruContentEntry).key)
+	}
+	return nil
+}
+
+func (c *LRUContentCheckCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// RedisContentCheckCache 多副本共享的缓存
+type RedisContentCheckCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisContentCheckCache prefix为空时使用 "content_check:"
+func NewRedisContentCheckCache(client redis.Cmdable, prefix string) *RedisContentCheckCache {
+	if prefix == "" {
+		prefix = defaultContentCachePrefix
+	}
+	return &RedisContentCheckCache{client: client, prefix: prefix}
+}
+
+func (c *RedisContentCheckCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *RedisContentCheckCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// singleflight 相同键同时只执行一次，其他调用等待并共用结果
+type singleflight struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+type singleflightCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// do 返回的shared表示结果来自其他调用；fn在单独的goroutine中执行，
+// 每个调用方只等待到自己的ctx结束，ctx结束时返回ctx.Err()，不影响其他调用方
+func (g *singleflight) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall)
+	}
+	call, shared := g.calls[key]
+	if !shared {
+		call = &singleflightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go func() {
+			defer func() {
+				g.mu.Lock()
+				delete(g.calls, key)
+				g.mu.Unlock()
+				close(call.done)
+			}()
+			call.value, call.err = fn()
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, shared, call.err
+	case <-ctx.Done():
+		return nil, shared, ctx.Err()
+	}
+}
@@ -0,0 +1,182 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nioliu/protocols/httpproto"
+)
+
+func TestLRUContentCheckCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUContentCheckCache(2)
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Minute)
+	// 访问a后b成为最久没有使用的
+	if v, ok, _ := cache.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Fatalf("expected a, got %q %v", v, ok)
+	}
+	cache.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+
+	cache.Set(ctx, "d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := cache.Get(ctx, "d"); ok {
+		t.Fatal("expected d to expire")
+	}
+}
+
+func TestRedisContentCheckCache(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	cache := NewRedisContentCheckCache(client, "")
+
+	if _, ok, err := cache.Get(ctx, "a"); err != nil || ok {
+		t.Fatalf("expected miss, got %v %v", ok, err)
+	}
+	if err := cache.Set(ctx, "a", []byte(`{"pass":true}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := cache.Get(ctx, "a"); err != nil || !ok || string(v) != `{"pass":true}` {
+		t.Fatalf("expected hit, got %q %v %v", v, ok, err)
+	}
+	if ttl := mr.TTL(defaultContentCachePrefix + "a"); ttl != time.Minute {
+		t.Fatalf("expected ttl 1m under default prefix, got %v", ttl)
+	}
+	mr.FastForward(time.Minute)
+	if _, ok, err := cache.Get(ctx, "a"); err != nil || ok {
+		t.Fatalf("expected expiry, got %v %v", ok, err)
+	}
+
+	// Redis不可用时返回错误，由 ContentChecker 记录日志后按未命中处理
+	mr.Close()
+	if _, _, err := cache.Get(ctx, "a"); err == nil {
+		t.Fatal("expected error when redis is down")
+	}
+}
+
+func TestContentCheckerCache(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL),
+		WithCheckerCache(NewLRUContentCheckCache(100), time.Minute))
+	ctx := context.Background()
+
+	// 同时进行的相同请求只请求一次
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := checker.Check(ctx, newCheckReq("same text")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 outbound request, got %d", n)
+	}
+
+	rsp, err := checker.Check(ctx, newCheckReq("same text"))
+	if err != nil || rsp == nil {
+		t.Fatalf("unexpected cached result %v %v", rsp, err)
+	}
+	if _, err = checker.Check(ctx, newCheckReq("other text")); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 outbound requests, got %d", n)
+	}
+
+	stats := checker.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 11 || stats.Shared != 9 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestContentCheckerCacheSkipsFallback(t *testing.T) {
+	server, calls := flakyDataService(t, http.StatusServiceUnavailable, 1)
+	fallback := func(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+		return &httpproto.CheckContentRsp{}, nil
+	}
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL), WithCheckerRetry(1, 0, 0),
+		WithCheckerCircuitBreaker(1, 50*time.Millisecond), WithCheckerFailOpen(fallback),
+		WithCheckerCache(NewLRUContentCheckCache(10), time.Minute))
+	ctx := context.Background()
+	if _, err := checker.Check(ctx, newCheckReq("text")); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := checker.Check(ctx, newCheckReq("text")); err != nil {
+		t.Fatal(err)
+	}
+	// 降级的结果没有缓存，恢复后重新请求数据服务
+	time.Sleep(60 * time.Millisecond)
+	if _, err := checker.Check(ctx, newCheckReq("text")); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 outbound requests, got %d", n)
+	}
+	if stats := checker.CacheStats(); stats.Hits != 0 || stats.Misses != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestContentCheckerCacheLeaderCanceled(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	checker := NewContentChecker(WithCheckerBaseURL(server.URL),
+		WithCheckerCache(NewLRUContentCheckCache(10), time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := checker.Check(ctx, newCheckReq("text"))
+		leader <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// 发起请求的调用取消后，等待同一结果的调用仍然可以拿到结果
+	follower := make(chan error, 1)
+	go func() {
+		_, err := checker.Check(context.Background(), newCheckReq("text"))
+		follower <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected leader canceled, got %v", err)
+	}
+	if err := <-follower; err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 outbound request, got %d", n)
+	}
+	if stats := checker.CacheStats(); stats.Shared != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
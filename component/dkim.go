@@ -0,0 +1,226 @@
+package component
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedDKIMKey = errors.New("dkim key must be rsa or ed25519")
+
+// 默认签名的邮件头，不存在的会跳过，From必须存在
+var defaultDKIMHeaders = []string{"From", "To", "Cc", "Subject", "Date", "Message-Id", "Reply-To",
+	"Mime-Version", "Content-Type"}
+
+// DKIMSigner 按RFC 6376对邮件签名，使用relaxed/relaxed规范化，支持rsa-sha256和ed25519-sha256（RFC 8463）
+type DKIMSigner struct {
+	domain    string
+	selector  string
+	key       crypto.Signer
+	algorithm string
+	headers   []string
+	now       func() time.Time
+}
+
+type DKIMOption func(s *DKIMSigner)
+
+// WithDKIMHeaders 设置需要签名的邮件头
+func WithDKIMHeaders(headers ...string) DKIMOption {
+	return func(s *DKIMSigner) {
+		s.headers = headers
+	}
+}
+
+// NewDKIMSigner domain和selector对应DNS中 <selector>._domainkey.<domain> 的TXT记录
+func NewDKIMSigner(domain, selector string, key crypto.Signer, opts ...DKIMOption) (*DKIMSigner, error) {
+	s := &DKIMSigner{domain: domain, selector: selector, key: key, headers: defaultDKIMHeaders, now: time.Now}
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		s.algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.algorithm = "ed25519-sha256"
+	default:
+		return nil, ErrUnsupportedDKIMKey
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
+}
+
+// ParseDKIMPrivateKey 解析PEM格式的PKCS#1或PKCS#8私钥
+func ParseDKIMPrivateKey(pemData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no pem block found in dkim key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedDKIMKey
+	}
+	switch signer.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+		return signer, nil
+	}
+	return nil, ErrUnsupportedDKIMKey
+}
+
+// Sign 返回在最前面加上 DKIM-Signature 头的邮件，raw需要使用CRLF换行
+func (s *DKIMSigner) Sign(raw []byte) ([]byte, error) {
+	header, body := splitMessage(raw)
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	fields := parseHeaderFields(header)
+	signed := make([]string, 0, len(s.headers))
+	names := make([]string, 0, len(s.headers))
+	for _, name := range s.headers {
+		// 同名的头取最后一个
+		for i := len(fields) - 1; i >= 0; i-- {
+			if strings.EqualFold(fieldName(fields[i]), name) {
+				signed = append(signed, fields[i])
+				names = append(names, strings.ToLower(name))
+				break
+			}
+		}
+	}
+	if !containsString(names, "from") {
+		return nil, errors.New("dkim: message has no From header")
+	}
+
+	value := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%s; h=%s; bh=%s; b=",
+		s.algorithm, s.domain, s.selector, strconv.FormatInt(s.now().Unix(), 10),
+		strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	h := sha256.New()
+	for _, f := range signed {
+		h.Write(relaxedHeader(f))
+	}
+	// 签名头本身不带末尾的CRLF
+	h.Write(bytes.TrimSuffix(relaxedHeader("DKIM-Signature: "+value), []byte("\r\n")))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch s.key.(type) {
+	case ed25519.PrivateKey:
+		// RFC 8463 对SHA-256摘要做PureEdDSA签名
+		signature, err = s.key.Sign(rand.Reader, digest, crypto.Hash(0))
+	default:
+		signature, err = s.key.Sign(rand.Reader, digest, crypto.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(raw)+len(value)+128)
+	out = append(out, "DKIM-Signature: "...)
+	out = append(out, value...)
+	out = append(out, base64.StdEncoding.EncodeToString(signature)...)
+	out = append(out, "\r\n"...)
+	return append(out, raw...), nil
+}
+
+func splitMessage(raw []byte) ([]byte, []byte) {
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		return raw[:i+2], raw[i+4:]
+	}
+	return raw, nil
+}
+
+// parseHeaderFields 按字段切分邮件头，折行的内容归到上一个字段
+func parseHeaderFields(header []byte) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+func fieldName(field string) string {
+	if i := strings.IndexByte(field, ':'); i >= 0 {
+		return strings.TrimSpace(field[:i])
+	}
+	return ""
+}
+
+// relaxedHeader RFC 6376 3.4.2：名称小写，去掉折行，连续空白合并为一个空格，去掉首尾空白
+func relaxedHeader(field string) []byte {
+	i := strings.IndexByte(field, ':')
+	if i < 0 {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSpace(field[:i]))
+	value := strings.ReplaceAll(field[i+1:], "\r\n", "")
+	value = strings.TrimSpace(collapseWhitespace(value))
+	return []byte(name + ":" + value + "\r\n")
+}
+
+// relaxedBody RFC 6376 3.4.4：去掉行尾空白，连续空白合并为一个空格，去掉末尾的空行
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(collapseWhitespace(line), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
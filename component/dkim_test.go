@@ -0,0 +1,170 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/jordan-wright/email"
+)
+
+func TestDKIMCanonicalization(t *testing.T) {
+	// RFC 6376 3.4.5 的例子
+	header := []byte("A: X\r\nB : Y\t\r\n\tZ  \r\n")
+	var got []byte
+	for _, f := range parseHeaderFields(header) {
+		got = append(got, relaxedHeader(f)...)
+	}
+	if string(got) != "a:X\r\nb:Y Z\r\n" {
+		t.Fatalf("unexpected relaxed header %q", got)
+	}
+
+	body := []byte(" C \r\nD \t E\r\n\r\n\r\n")
+	if got = relaxedBody(body); string(got) != " C\r\nD E\r\n" {
+		t.Fatalf("unexpected relaxed body %q", got)
+	}
+	if got = relaxedBody([]byte("\r\n\r\n")); len(got) != 0 {
+		t.Fatalf("expected empty body, got %q", got)
+	}
+}
+
+func TestDKIMSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &email.Email{From: "Sender <from@example.com>", To: []string{"to@example.com"},
+		Subject: "hello", Text: []byte("body line  \r\n\r\n")}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []crypto.Signer{rsaKey, edKey} {
+		signer, err := NewDKIMSigner("example.com", "mail", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed, err := signer.Sign(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(signed, []byte("DKIM-Signature: v=1; ")) || !bytes.HasSuffix(signed, raw) {
+			t.Fatalf("unexpected signed message:\n%s", signed)
+		}
+		if reason := verifyDKIM(signed, key.Public()); reason != "" {
+			t.Fatalf("%T: %s", key, reason)
+		}
+
+		// 修改正文后签名失效
+		tampered := bytes.Replace(signed, []byte("body line"), []byte("body lime"), 1)
+		if verifyDKIM(tampered, key.Public()) == "" {
+			t.Fatalf("%T: expected tampered message to fail", key)
+		}
+	}
+
+	if _, err = NewDKIMSigner("example.com", "mail", nil); err != ErrUnsupportedDKIMKey {
+		t.Fatalf("expected ErrUnsupportedDKIMKey, got %v", err)
+	}
+	signer, _ := NewDKIMSigner("example.com", "mail", edKey)
+	if _, err = signer.Sign([]byte("Subject: no from\r\n\r\nbody")); err == nil {
+		t.Fatal("expected error without From header")
+	}
+}
+
+func TestParseDKIMPrivateKey(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseDKIMPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edKey.Equal(key) {
+		t.Fatal("parsed key does not match")
+	}
+	if _, err = ParseDKIMPrivateKey([]byte("not pem")); err == nil {
+		t.Fatal("expected error for invalid pem")
+	}
+}
+
+func TestSMTPMailerDKIM(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := NewDKIMSigner("example.com", "mail", edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.lis.Addr().String(), nil, WithDKIM(signer))
+	defer mailer.Close()
+	msg := &email.Email{From: "from@example.com", To: []string{"to@example.com"}, Subject: "s", Text: []byte("hi")}
+	if err = mailer.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	received := server.received()
+	if len(received) != 1 || !strings.HasPrefix(received[0], "DKIM-Signature: ") {
+		t.Fatalf("expected signed message, got %v", received)
+	}
+}
+
+// verifyDKIM 按接收方的方式校验第一个DKIM-Signature头，成功时返回空字符串
+func verifyDKIM(signed []byte, pub crypto.PublicKey) string {
+	header, body := splitMessage(signed)
+	fields := parseHeaderFields(header)
+	sigField := fields[0]
+	tags := map[string]string{}
+	for _, tag := range strings.Split(strings.SplitN(sigField, ":", 2)[1], ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		tags[k] = strings.Join(strings.Fields(v), "")
+	}
+
+	bh := sha256.Sum256(relaxedBody(body))
+	if base64.StdEncoding.EncodeToString(bh[:]) != tags["bh"] {
+		return "body hash mismatch"
+	}
+
+	h := sha256.New()
+	rest := fields[1:]
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(rest) - 1; i >= 0; i-- {
+			if strings.EqualFold(fieldName(rest[i]), name) {
+				h.Write(relaxedHeader(rest[i]))
+				break
+			}
+		}
+	}
+	withoutB := sigField[:strings.LastIndex(sigField, "b=")+2]
+	h.Write(bytes.TrimSuffix(relaxedHeader(withoutB), []byte("\r\n")))
+	digest := h.Sum(nil)
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err.Error()
+	}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig); err != nil {
+			return err.Error()
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, digest, sig) {
+			return "ed25519 signature mismatch"
+		}
+	}
+	return ""
+}
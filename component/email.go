@@ -1,10 +1,15 @@
 package component
 
 import (
+	"context"
+	"errors"
 	"github.com/jordan-wright/email"
-	"net/smtp"
+	"os"
+	"path/filepath"
 )
 
+var ErrEmailTemplatesNotSet = errors.New("email templates not set, use WithEmailTemplates")
+
 type EmailVerifier struct {
 	email *email.Email
 
@@ -14,17 +19,55 @@ type EmailVerifier struct {
 	EmailServerAddr string
 
 	CodeFromUser string
+
+	templates         *EmailTemplates
+	mailer            Mailer // 为空时使用上面的SMTP配置
+	maxAttachmentSize int64
+	authMechanism     string
+	smtpOptions       []SMTPOption
+	rateLimiter       *EmailRateLimiter
+}
+
+type EmailOption func(e *EmailVerifier)
+
+// WithEmailTemplates 设置 SendTemplateEmail 使用的模板
+func WithEmailTemplates(templates *EmailTemplates) EmailOption {
+	return func(e *EmailVerifier) {
+		e.templates = templates
+	}
+}
+
+// WithMailer 设置发送方式，比如测试时使用 MemoryMailer
+func WithMailer(mailer Mailer) EmailOption {
+	return func(e *EmailVerifier) {
+		e.mailer = mailer
+	}
+}
+
+// WithSMTPAuthMechanism 没有设置Mailer时使用的认证方式：PLAIN（默认）、LOGIN、CRAM-MD5
+func WithSMTPAuthMechanism(mechanism string) EmailOption {
+	return func(e *EmailVerifier) {
+		e.authMechanism = mechanism
+	}
+}
+
+// WithSMTPOptions 没有设置Mailer时创建SMTP连接的配置，比如 WithSMTPTLS、WithDKIM
+func WithSMTPOptions(opts ...SMTPOption) EmailOption {
+	return func(e *EmailVerifier) {
+		e.smtpOptions = append(e.smtpOptions, opts...)
+	}
 }
 
 func newEmailVerifier(email *email.Email, fromEmailName string, fromEmailPass string,
 	emailServerHost string, emailServerAddr string) *EmailVerifier {
 	return &EmailVerifier{email: email, FromEmailName: fromEmailName,
 		FromEmailPass: fromEmailPass, EmailServerHost: emailServerHost,
-		EmailServerAddr: emailServerAddr}
+		EmailServerAddr: emailServerAddr, maxAttachmentSize: defaultMaxAttachmentSize}
 }
 
-func GetNewEmail(to []string, bcc []string, From, FromEmailName, FromEmailPass, emailServerHost, emailServerAddr string) *EmailVerifier {
-	return newEmailVerifier(&email.Email{
+func GetNewEmail(to []string, bcc []string, From, FromEmailName, FromEmailPass, emailServerHost, emailServerAddr string,
+	opts ...EmailOption) *EmailVerifier {
+	e := newEmailVerifier(&email.Email{
 		From: From, // 发送者
 		To:   to,   // 收件人
 		Bcc:  bcc,  // 抄送
@@ -32,27 +75,92 @@ func GetNewEmail(to []string, bcc []string, From, FromEmailName, FromEmailPass,
 		FromEmailPass,
 		emailServerHost,
 		emailServerAddr)
+	for _, o := range opts {
+		o(e)
+	}
+	return e
 }
 
 func (e *EmailVerifier) SendContentEmail(subject string, content []byte, attaches ...string) error {
 	e.email.Text = content
+	e.email.HTML = nil
 	e.email.Subject = subject
 
-	if attaches != nil {
-		for _, f := range attaches {
-			_, err := e.email.AttachFile(f)
+	return e.send(context.Background(), attaches...)
+}
+
+// SendTemplateEmail 按ctx中的地区渲染模板，同时有HTML和纯文本模板时发送multipart/alternative邮件；
+// 模板中没有标题时使用subject
+func (e *EmailVerifier) SendTemplateEmail(ctx context.Context, subject, name string, data interface{},
+	attaches ...string) error {
+	if err := e.render(ctx, subject, name, data); err != nil {
+		return err
+	}
+	return e.send(ctx, attaches...)
+}
+
+func (e *EmailVerifier) render(ctx context.Context, subject, name string, data interface{}) error {
+	if e.templates == nil {
+		return ErrEmailTemplatesNotSet
+	}
+	rendered, err := e.templates.Render(LocaleFromContext(ctx), name, data)
+	if err != nil {
+		return err
+	}
+	if rendered.Subject != "" {
+		subject = rendered.Subject
+	}
+	e.email.Subject = subject
+	e.email.Text = rendered.Text
+	e.email.HTML = rendered.HTML
+	return nil
+}
+
+// send 发送后清空附件，复用同一个EmailVerifier时不会重复带上之前的附件；发送失败时归还限流额度
+func (e *EmailVerifier) send(ctx context.Context, attaches ...string) (err error) {
+	defer func() { e.email.Attachments = nil }()
+	if e.rateLimiter != nil {
+		recipients := append(append(append([]string(nil), e.email.To...), e.email.Cc...), e.email.Bcc...)
+		var release func()
+		if release, err = e.rateLimiter.Acquire(ctx, RateLimitCallerFromContext(ctx), recipients); err != nil {
+			return err
+		}
+		defer func() {
 			if err != nil {
-				return err
+				release()
 			}
+		}()
+	}
+	for _, f := range attaches {
+		if err := e.attachFile(f); err != nil {
+			return err
 		}
 	}
 
 	// send email
-	plainAuth := smtp.PlainAuth("", e.FromEmailName, e.FromEmailPass, e.EmailServerHost)
-
-	if err := e.email.Send(e.EmailServerAddr, plainAuth); err != nil {
+	mailer := e.mailer
+	if mailer == nil {
+		// 没有设置Mailer时每次单独连接，需要复用连接时使用 WithMailer 共享一个 SMTPMailer
+		auth, err := NewSMTPAuth(e.authMechanism, e.FromEmailName, e.FromEmailPass, e.EmailServerHost)
+		if err != nil {
+			return err
+		}
+		smtpMailer := NewSMTPMailer(e.EmailServerAddr, auth, append([]SMTPOption{WithSMTPPoolSize(1)}, e.smtpOptions...)...)
+		defer smtpMailer.Close()
+		mailer = smtpMailer
+	}
+	if err := mailer.Send(ctx, e.email); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (e *EmailVerifier) attachFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return e.Attach(AttachReader(filepath.Base(path), "", f))
+}
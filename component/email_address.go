@@ -0,0 +1,183 @@
+package component
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	ErrInvalidEmailAddress   = errors.New("invalid email address")
+	ErrDisposableEmailDomain = errors.New("disposable email domains are not allowed")
+	ErrDuplicateEmailAddress = errors.New("duplicate email address")
+	ErrInvalidRecipients     = errors.New("some recipients are invalid")
+)
+
+// DefaultDisposableDomains 常见的一次性邮箱域名，子域名同样会被拒绝
+var DefaultDisposableDomains = []string{
+	"10minutemail.com", "dispostable.com", "guerrillamail.com", "guerrillamailblock.com", "mailinator.com",
+	"maildrop.cc", "sharklasers.com", "temp-mail.org", "tempmail.com", "throwawaymail.com", "trashmail.com",
+	"yopmail.com",
+}
+
+// AddressResult 单个地址的校验结果，Err不为空时地址无效
+type AddressResult struct {
+	Input   string // 原始输入
+	Address string // 规范化后的地址，小写并且域名转换为punycode，可以直接用于发送
+	Key     string // 去重使用的键，开启 WithStripPlusAlias 时去掉了+后面的别名
+	Err     error
+}
+
+func (r AddressResult) Valid() bool {
+	return r.Err == nil
+}
+
+// AddressValidator 校验并规范化收件人地址，结果按地址逐个返回，方便注册页面提示具体哪个字段有误
+type AddressValidator struct {
+	stripPlus  bool
+	disposable map[string]bool
+}
+
+type AddressValidatorOption func(v *AddressValidator)
+
+// WithStripPlusAlias 去重时忽略+后面的别名，a+1@example.com 和 a@example.com 视为同一个地址
+func WithStripPlusAlias() AddressValidatorOption {
+	return func(v *AddressValidator) {
+		v.stripPlus = true
+	}
+}
+
+// WithDisposableDomains 替换一次性邮箱域名列表，默认为 DefaultDisposableDomains，不传参数时不拦截
+func WithDisposableDomains(domains ...string) AddressValidatorOption {
+	return func(v *AddressValidator) {
+		v.disposable = make(map[string]bool, len(domains))
+		for _, d := range domains {
+			if ascii, err := normalizeDomain(d); err == nil {
+				v.disposable[ascii] = true
+			}
+		}
+	}
+}
+
+func NewAddressValidator(opts ...AddressValidatorOption) *AddressValidator {
+	v := &AddressValidator{}
+	WithDisposableDomains(DefaultDisposableDomains...)(v)
+	for _, o := range opts {
+		o(v)
+	}
+	return v
+}
+
+// LoadDisposableDomains 读取一行一个域名的列表，忽略空行和#开头的注释
+func LoadDisposableDomains(r io.Reader) ([]string, error) {
+	return readListLines(r)
+}
+
+func readListLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// Validate 按输入顺序返回每个地址的结果，重复的地址从第二个开始返回ErrDuplicateEmailAddress
+func (v *AddressValidator) Validate(addresses ...string) []AddressResult {
+	results := make([]AddressResult, len(addresses))
+	seen := make(map[string]bool, len(addresses))
+	for i, a := range addresses {
+		results[i] = v.ValidateOne(a)
+		if !results[i].Valid() {
+			continue
+		}
+		if seen[results[i].Key] {
+			results[i].Err = ErrDuplicateEmailAddress
+			continue
+		}
+		seen[results[i].Key] = true
+	}
+	return results
+}
+
+// ValidateOne 校验单个地址，允许带显示名称，比如 "Bob <bob@example.com>"
+func (v *AddressValidator) ValidateOne(input string) AddressResult {
+	res := AddressResult{Input: input}
+	parsed, err := mail.ParseAddress(strings.TrimSpace(input))
+	if err != nil {
+		res.Err = fmt.Errorf("%w: %v", ErrInvalidEmailAddress, err)
+		return res
+	}
+	at := strings.LastIndexByte(parsed.Address, '@')
+	local, domain := strings.ToLower(parsed.Address[:at]), parsed.Address[at+1:]
+	ascii, err := normalizeDomain(domain)
+	// 注册邮箱必须是带点的公网域名
+	if err != nil || !strings.Contains(ascii, ".") {
+		res.Err = fmt.Errorf("%w: bad domain %q", ErrInvalidEmailAddress, domain)
+		return res
+	}
+	if v.isDisposable(ascii) {
+		res.Err = ErrDisposableEmailDomain
+		return res
+	}
+
+	res.Address = local + "@" + ascii
+	key := local
+	if v.stripPlus {
+		if i := strings.IndexByte(key, '+'); i > 0 {
+			key = key[:i]
+		}
+	}
+	res.Key = key + "@" + ascii
+	return res
+}
+
+// isDisposable 域名本身或者任意一级父域名在列表中
+func (v *AddressValidator) isDisposable(domain string) bool {
+	for {
+		if v.disposable[domain] {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// normalizeDomain 国际化域名转换为小写的punycode
+func normalizeDomain(domain string) (string, error) {
+	return idna.Lookup.ToASCII(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// ValidateRecipients 校验收件人和密送地址，全部有效时替换为规范化后的地址；
+// 有无效地址时返回ErrInvalidRecipients，具体原因见每个地址的结果，顺序为收件人在前密送在后
+func (e *EmailVerifier) ValidateRecipients(v *AddressValidator) ([]AddressResult, error) {
+	results := v.Validate(append(append([]string(nil), e.email.To...), e.email.Bcc...)...)
+	for _, r := range results {
+		if !r.Valid() {
+			return results, ErrInvalidRecipients
+		}
+	}
+	n := len(e.email.To)
+	e.email.To = make([]string, 0, n)
+	e.email.Bcc = make([]string, 0, len(results)-n)
+	for i, r := range results {
+		if i < n {
+			e.email.To = append(e.email.To, r.Address)
+		} else {
+			e.email.Bcc = append(e.email.Bcc, r.Address)
+		}
+	}
+	return results, nil
+}
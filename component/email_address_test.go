@@ -0,0 +1,84 @@
+package component
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddressValidator(t *testing.T) {
+	v := NewAddressValidator(WithStripPlusAlias())
+	results := v.Validate(
+		"Bob <Bob@Example.COM>",
+		"bob+news@example.com",
+		"用户@例子.中国",
+		"not-an-address",
+		"user@localhost",
+		"someone@mailinator.com",
+		"someone@eu.yopmail.com",
+	)
+
+	expect := []struct {
+		address string
+		err     error
+	}{
+		{"bob@example.com", nil},
+		{"bob+news@example.com", ErrDuplicateEmailAddress},
+		{"用户@xn--fsqu00a.xn--fiqs8s", nil},
+		{"", ErrInvalidEmailAddress},
+		{"", ErrInvalidEmailAddress},
+		{"", ErrDisposableEmailDomain},
+		{"", ErrDisposableEmailDomain},
+	}
+	for i, e := range expect {
+		r := results[i]
+		if e.err == nil && !r.Valid() || e.err != nil && !errors.Is(r.Err, e.err) {
+			t.Fatalf("%q: expected error %v, got %v", r.Input, e.err, r.Err)
+		}
+		if r.Address != e.address {
+			t.Fatalf("%q: expected address %q, got %q", r.Input, e.address, r.Address)
+		}
+	}
+	if results[1].Key != "bob@example.com" {
+		t.Fatalf("expected plus alias stripped from key, got %q", results[1].Key)
+	}
+
+	// 不去掉别名时是不同的地址
+	if r := NewAddressValidator().Validate("bob@example.com", "bob+news@example.com"); !r[1].Valid() {
+		t.Fatalf("expected alias to be distinct, got %v", r[1].Err)
+	}
+}
+
+func TestDisposableDomainsConfig(t *testing.T) {
+	domains, err := LoadDisposableDomains(strings.NewReader("# custom list\nspam.test\n\n  Junk.Example  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewAddressValidator(WithDisposableDomains(domains...))
+	if r := v.ValidateOne("a@junk.example"); !errors.Is(r.Err, ErrDisposableEmailDomain) {
+		t.Fatalf("expected disposable domain error, got %v", r.Err)
+	}
+	// 替换后默认列表不再生效
+	if r := v.ValidateOne("a@mailinator.com"); !r.Valid() {
+		t.Fatalf("expected default list to be replaced, got %v", r.Err)
+	}
+}
+
+func TestValidateRecipients(t *testing.T) {
+	e := GetNewEmail([]string{"A@Example.com"}, []string{"B@例子.中国"}, "from@example.com", "", "", "", "")
+	if _, err := e.ValidateRecipients(NewAddressValidator()); err != nil {
+		t.Fatal(err)
+	}
+	if e.email.To[0] != "a@example.com" || e.email.Bcc[0] != "b@xn--fsqu00a.xn--fiqs8s" {
+		t.Fatalf("expected normalized recipients, got %v %v", e.email.To, e.email.Bcc)
+	}
+
+	e = GetNewEmail([]string{"ok@example.com", "bad"}, nil, "from@example.com", "", "", "", "")
+	results, err := e.ValidateRecipients(NewAddressValidator())
+	if !errors.Is(err, ErrInvalidRecipients) || !results[0].Valid() || results[1].Valid() {
+		t.Fatalf("unexpected results %v, %v", results, err)
+	}
+	if e.email.To[1] != "bad" {
+		t.Fatal("recipients should be unchanged when invalid")
+	}
+}
@@ -0,0 +1,106 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordan-wright/email"
+)
+
+var ErrAttachmentTooLarge = errors.New("email attachments exceed size limit")
+
+const defaultMaxAttachmentSize = 20 << 20
+
+// EmailAttachment 内存中的附件，Content为空时读取Reader
+type EmailAttachment struct {
+	Filename    string
+	ContentType string // 为空时按文件扩展名推断
+	Content     []byte
+	Reader      io.Reader
+	ContentID   string // 不为空时作为内嵌资源，HTML中通过 <img src="cid:ContentID"> 引用
+}
+
+// AttachBytes 字节内容的附件，比如生成的CSV账单
+func AttachBytes(filename, contentType string, content []byte) EmailAttachment {
+	return EmailAttachment{Filename: filename, ContentType: contentType, Content: content}
+}
+
+// AttachReader 从io.Reader读取的附件，发送前会全部读入内存
+func AttachReader(filename, contentType string, r io.Reader) EmailAttachment {
+	return EmailAttachment{Filename: filename, ContentType: contentType, Reader: r}
+}
+
+// InlineImage HTML正文中内嵌的图片
+func InlineImage(contentID, filename, contentType string, content []byte) EmailAttachment {
+	return EmailAttachment{Filename: filename, ContentType: contentType, Content: content, ContentID: contentID}
+}
+
+// WithMaxAttachmentSize 所有附件加起来的最大字节数，默认20MB
+func WithMaxAttachmentSize(size int64) EmailOption {
+	return func(e *EmailVerifier) {
+		e.maxAttachmentSize = size
+	}
+}
+
+// Attach 添加附件，下一次发送时带上，发送后清空；超过大小限制时返回ErrAttachmentTooLarge
+func (e *EmailVerifier) Attach(attachments ...EmailAttachment) error {
+	for _, a := range attachments {
+		if a.Filename == "" {
+			return errors.New("attachment filename is required")
+		}
+		total := e.attachmentSize()
+		content := a.Content
+		if content == nil && a.Reader != nil {
+			// 最多多读一个字节，超出限制时不会把整个Reader读进内存
+			var err error
+			if content, err = io.ReadAll(io.LimitReader(a.Reader, e.maxAttachmentSize-total+1)); err != nil {
+				return err
+			}
+		}
+		if total+int64(len(content)) > e.maxAttachmentSize {
+			return fmt.Errorf("%w: %s makes total more than %d bytes", ErrAttachmentTooLarge,
+				a.Filename, e.maxAttachmentSize)
+		}
+
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+		}
+		at := &email.Attachment{
+			Filename:    sanitizeFilename(a.Filename),
+			ContentType: contentType,
+			Header:      textproto.MIMEHeader{},
+			Content:     content,
+		}
+		if a.ContentID != "" {
+			at.HTMLRelated = true
+			at.Header.Set("Content-ID", "<"+strings.Trim(a.ContentID, "<>")+">")
+		}
+		e.email.Attachments = append(e.email.Attachments, at)
+	}
+	return nil
+}
+
+func (e *EmailVerifier) attachmentSize() int64 {
+	var total int64
+	for _, a := range e.email.Attachments {
+		total += int64(len(a.Content))
+	}
+	return total
+}
+
+// sanitizeFilename 文件名会写入邮件头，去掉引号和换行
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '"', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, filepath.Base(name))
+}
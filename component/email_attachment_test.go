@@ -0,0 +1,74 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmailAttachments(t *testing.T) {
+	mailer := NewMemoryMailer()
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "", WithMailer(mailer))
+
+	png := []byte{0x89, 'P', 'N', 'G'}
+	err := e.Attach(
+		AttachBytes("invoice.csv", "text/csv", []byte("id,amount\n1,100\n")),
+		AttachReader("report.txt", "", strings.NewReader("report")),
+		InlineImage("logo", "logo.png", "", png),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.email.HTML = []byte(`<img src="cid:logo">`)
+	if err = e.send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := mailer.Last().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"multipart/related",
+		"Content-Id: <logo>",
+		`filename="invoice.csv"`,
+		"Content-Type: text/csv",
+		"Content-Type: image/png",
+		"Content-Type: text/plain",
+	} {
+		if !bytes.Contains(raw, []byte(expected)) {
+			t.Fatalf("expected %q in message:\n%s", expected, raw)
+		}
+	}
+	if len(e.email.Attachments) != 0 {
+		t.Fatal("attachments should be cleared after sending")
+	}
+}
+
+func TestEmailAttachmentSizeLimit(t *testing.T) {
+	mailer := NewMemoryMailer()
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "",
+		WithMailer(mailer), WithMaxAttachmentSize(10))
+
+	if err := e.Attach(AttachBytes("a.bin", "", make([]byte, 6))); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Attach(AttachReader("b.bin", "", bytes.NewReader(make([]byte, 5)))); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Fatalf("expected ErrAttachmentTooLarge, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, 11), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SendContentEmail("subject", []byte("body"), path); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Fatalf("expected ErrAttachmentTooLarge for file, got %v", err)
+	}
+	if mailer.Last() != nil {
+		t.Fatal("message over the limit should not be sent")
+	}
+}
@@ -0,0 +1,360 @@
+package component
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nioliu/commons/errs"
+)
+
+var ErrVerifyCodeRecipient = errors.New("verification code email must have exactly one recipient")
+
+const (
+	digitCodeCharset        = "0123456789"
+	alphanumericCodeCharset = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ" // 去掉了容易混淆的0 1 I O
+
+	defaultVerifyCodeLength      = 6
+	defaultVerifyCodeTTL         = 10 * time.Minute
+	defaultVerifyCodeMaxAttempts = 5
+	defaultVerifyCodeCooldown    = time.Minute
+	defaultVerifyCodeKeyPrefix   = "verify_code:"
+	defaultVerifyCodeTemplate    = "verify_code"
+	defaultVerifyCodeSubject     = "Verification code"
+)
+
+// VerifyCodeResult 校验结果
+type VerifyCodeResult int
+
+const (
+	VerifyCodeOK VerifyCodeResult = iota
+	VerifyCodeMismatch
+	VerifyCodeExpired
+	VerifyCodeTooManyAttempts
+)
+
+// VerifyCodeStore 验证码哈希的存储，多副本部署时必须共享，否则换一个副本就可以绕过冷却和尝试次数
+type VerifyCodeStore interface {
+	// Issue 冷却期内返回false和剩余的冷却时间；否则保存哈希、重置尝试次数并开始冷却
+	Issue(ctx context.Context, key, hash string, ttl, cooldown time.Duration) (bool, time.Duration, error)
+	// Verify 尝试次数加一后比较哈希，超过maxAttempts后不再比较，匹配成功后删除
+	Verify(ctx context.Context, key, hash string, maxAttempts int) (VerifyCodeResult, error)
+	// Clear 删除验证码和冷却状态
+	Clear(ctx context.Context, key string) error
+}
+
+var (
+	issueVerifyCodeScript = redis.NewScript(`
+if tonumber(ARGV[3]) > 0 then
+	if not redis.call("SET", KEYS[2], 1, "PX", ARGV[3], "NX") then
+		return {0, redis.call("PTTL", KEYS[2])}
+	end
+end
+redis.call("DEL", KEYS[1])
+redis.call("HSET", KEYS[1], "hash", ARGV[1], "attempts", 0)
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return {1, 0}`)
+	verifyCodeScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 2
+end
+if redis.call("HINCRBY", KEYS[1], "attempts", 1) > tonumber(ARGV[2]) then
+	return 3
+end
+if redis.call("HGET", KEYS[1], "hash") == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+return 1`)
+)
+
+// RedisVerifyCodeStore 验证码保存在hash中，冷却状态单独一个键，都通过脚本原子地更新
+type RedisVerifyCodeStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisVerifyCodeStore(client redis.Cmdable) *RedisVerifyCodeStore {
+	return &RedisVerifyCodeStore{client: client}
+}
+
+func (s *RedisVerifyCodeStore) Issue(ctx context.Context, key, hash string, ttl, cooldown time.Duration) (bool, time.Duration, error) {
+	res, err := issueVerifyCodeScript.Run(ctx, s.client, []string{key, cooldownKey(key)},
+		hash, ttl.Milliseconds(), cooldown.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+func (s *RedisVerifyCodeStore) Verify(ctx context.Context, key, hash string, maxAttempts int) (VerifyCodeResult, error) {
+	n, err := verifyCodeScript.Run(ctx, s.client, []string{key}, hash, maxAttempts).Int()
+	if err != nil {
+		return VerifyCodeExpired, err
+	}
+	return VerifyCodeResult(n), nil
+}
+
+func (s *RedisVerifyCodeStore) Clear(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key, cooldownKey(key)).Err()
+}
+
+func cooldownKey(key string) string {
+	return key + ":cooldown"
+}
+
+// MemoryVerifyCodeStore 验证码和冷却保存在map中，过期的验证码在下一次验证时才删除
+type MemoryVerifyCodeStore struct {
+	mu        sync.Mutex
+	codes     map[string]*memoryVerifyCode
+	cooldowns map[string]time.Time
+}
+
+type memoryVerifyCode struct {
+	hash     string
+	attempts int
+	expireAt time.Time
+}
+
+func NewMemoryVerifyCodeStore() *MemoryVerifyCodeStore {
+	return &MemoryVerifyCodeStore{
+		codes:     make(map[string]*memoryVerifyCode),
+		cooldowns: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryVerifyCodeStore) Issue(ctx context.Context, key, hash string, ttl, cooldown time.Duration) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := s.cooldowns[key]; ok && now.Before(until) {
+		return false, until.Sub(now), nil
+	}
+	if cooldown > 0 {
+		s.cooldowns[key] = now.Add(cooldown)
+	}
+	s.codes[key] = &memoryVerifyCode{hash: hash, expireAt: now.Add(ttl)}
+	return true, 0, nil
+}
+
+func (s *MemoryVerifyCodeStore) Verify(ctx context.Context, key, hash string, maxAttempts int) (VerifyCodeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.codes[key]
+	if !ok || !time.Now().Before(code.expireAt) {
+		delete(s.codes, key)
+		return VerifyCodeExpired, nil
+	}
+	code.attempts++
+	if code.attempts > maxAttempts {
+		return VerifyCodeTooManyAttempts, nil
+	}
+	if code.hash == hash {
+		delete(s.codes, key)
+		return VerifyCodeOK, nil
+	}
+	return VerifyCodeMismatch, nil
+}
+
+func (s *MemoryVerifyCodeStore) Clear(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.codes, key)
+	delete(s.cooldowns, key)
+	return nil
+}
+
+// VerifyCodeData 验证码邮件模板的数据
+type VerifyCodeData struct {
+	Code          string
+	Purpose       string
+	Address       string
+	ExpireMinutes int
+}
+
+// VerifyCodeManager 生成、发送、校验邮件验证码；存储中只保存验证码的HMAC，按用途和邮箱地址区分
+type VerifyCodeManager struct {
+	store       VerifyCodeStore
+	length      int
+	charset     string
+	ttl         time.Duration
+	maxAttempts int
+	cooldown    time.Duration
+	keyPrefix   string
+	secret      []byte
+	template    string
+	subject     string
+}
+
+type VerifyCodeOption func(m *VerifyCodeManager)
+
+// WithCodeLength 验证码长度，默认6位
+func WithCodeLength(length int) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.length = length
+	}
+}
+
+// WithAlphanumericCode 使用数字和大写字母，默认只使用数字
+func WithAlphanumericCode() VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.charset = alphanumericCodeCharset
+	}
+}
+
+// WithCodeTTL 验证码有效期，默认10分钟
+func WithCodeTTL(ttl time.Duration) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.ttl = ttl
+	}
+}
+
+// WithMaxAttempts 同一个验证码最多校验几次，默认5次
+func WithMaxAttempts(n int) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.maxAttempts = n
+	}
+}
+
+// WithResendCooldown 重新发送的最短间隔，默认1分钟
+func WithResendCooldown(cooldown time.Duration) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.cooldown = cooldown
+	}
+}
+
+// WithCodeKeyPrefix 存储键的前缀，默认 "verify_code:"
+func WithCodeKeyPrefix(prefix string) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.keyPrefix = prefix
+	}
+}
+
+// WithCodeSecret 计算HMAC的密钥，存储泄露时无法直接用哈希反查验证码
+func WithCodeSecret(secret []byte) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.secret = secret
+	}
+}
+
+// WithCodeTemplate 发送使用的模板名称和模板中没有标题时的默认标题，默认为 "verify_code"
+func WithCodeTemplate(name, subject string) VerifyCodeOption {
+	return func(m *VerifyCodeManager) {
+		m.template = name
+		m.subject = subject
+	}
+}
+
+func NewVerifyCodeManager(store VerifyCodeStore, opts ...VerifyCodeOption) *VerifyCodeManager {
+	m := &VerifyCodeManager{
+		store:       store,
+		length:      defaultVerifyCodeLength,
+		charset:     digitCodeCharset,
+		ttl:         defaultVerifyCodeTTL,
+		maxAttempts: defaultVerifyCodeMaxAttempts,
+		cooldown:    defaultVerifyCodeCooldown,
+		keyPrefix:   defaultVerifyCodeKeyPrefix,
+		template:    defaultVerifyCodeTemplate,
+		subject:     defaultVerifyCodeSubject,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// Send 生成验证码并通过e发送给它唯一的收件人，冷却期内返回 errs.GetVerifyCodeResendTooSoonError；
+// 发送失败时清除验证码和冷却状态，可以立即重试
+func (m *VerifyCodeManager) Send(ctx context.Context, e *EmailVerifier, purpose string) error {
+	if len(e.email.To) != 1 {
+		return ErrVerifyCodeRecipient
+	}
+	address := e.email.To[0]
+	code, err := m.generate()
+	if err != nil {
+		return err
+	}
+
+	key := m.key(purpose, address)
+	ok, wait, err := m.store.Issue(ctx, key, m.hash(key, code), m.ttl, m.cooldown)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.GetVerifyCodeResendTooSoonError().WithRetryAfter(wait).
+			WithDetail("retry after " + wait.Round(time.Second).String())
+	}
+
+	data := VerifyCodeData{Code: code, Purpose: purpose, Address: address, ExpireMinutes: int(m.ttl / time.Minute)}
+	if err = e.SendTemplateEmail(ctx, m.subject, m.template, data); err != nil {
+		_ = m.store.Clear(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// Verify 校验用户输入的验证码，失败时返回过期、不匹配、尝试次数过多对应的 errs.ErrRsp
+func (m *VerifyCodeManager) Verify(ctx context.Context, purpose, address, code string) error {
+	key := m.key(purpose, address)
+	code = strings.TrimSpace(code)
+	if m.charset == alphanumericCodeCharset {
+		code = strings.ToUpper(code)
+	}
+
+	res, err := m.store.Verify(ctx, key, m.hash(key, code), m.maxAttempts)
+	if err != nil {
+		return err
+	}
+	switch res {
+	case VerifyCodeOK:
+		return nil
+	case VerifyCodeMismatch:
+		return errs.GetVerifyCodeMismatchError()
+	case VerifyCodeTooManyAttempts:
+		return errs.GetVerifyCodeTooManyAttemptsError()
+	default:
+		return errs.GetVerifyCodeExpiredError()
+	}
+}
+
+// VerifyCode 校验 CodeFromUser 是否为发送给收件人的验证码
+func (e *EmailVerifier) VerifyCode(ctx context.Context, m *VerifyCodeManager, purpose string) error {
+	if len(e.email.To) != 1 {
+		return ErrVerifyCodeRecipient
+	}
+	return m.Verify(ctx, purpose, e.email.To[0], e.CodeFromUser)
+}
+
+func (m *VerifyCodeManager) generate() (string, error) {
+	max := big.NewInt(int64(len(m.charset)))
+	code := make([]byte, m.length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = m.charset[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func (m *VerifyCodeManager) key(purpose, address string) string {
+	return m.keyPrefix + purpose + ":" + normalizeAddress(address)
+}
+
+func (m *VerifyCodeManager) hash(key, code string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
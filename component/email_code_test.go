@@ -0,0 +1,195 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nioliu/commons/errs"
+)
+
+func TestVerifyCodeManager(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryVerifyCodeStore()
+	m := NewVerifyCodeManager(store, WithMaxAttempts(2), WithCodeSecret([]byte("secret")))
+
+	issue := func(address, code string) bool {
+		key := m.key("login", address)
+		ok, _, err := store.Issue(ctx, key, m.hash(key, code), m.ttl, m.cooldown)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	expectErr := func(err error, expected *errs.ErrRsp) {
+		t.Helper()
+		var errRsp *errs.ErrRsp
+		if !errors.As(err, &errRsp) || !expected.IsEqual(errRsp) {
+			t.Fatalf("expected %v, got %v", expected, err)
+		}
+	}
+
+	if !issue("User@Example.com", "123456") {
+		t.Fatal("expected code to be issued")
+	}
+	expectErr(m.Verify(ctx, "login", "user@example.com", "654321"), errs.GetVerifyCodeMismatchError())
+	expectErr(m.Verify(ctx, "register", "user@example.com", "123456"), errs.GetVerifyCodeExpiredError())
+	if err := m.Verify(ctx, "login", " user@example.com", "123456 "); err != nil {
+		t.Fatal(err)
+	}
+	// 验证成功后失效
+	expectErr(m.Verify(ctx, "login", "user@example.com", "123456"), errs.GetVerifyCodeExpiredError())
+
+	// 冷却期内不能重发
+	if issue("user@example.com", "111111") {
+		t.Fatal("expected resend cooldown")
+	}
+
+	if !issue("other@example.com", "222222") {
+		t.Fatal("expected code to be issued")
+	}
+	expectErr(m.Verify(ctx, "login", "other@example.com", "000000"), errs.GetVerifyCodeMismatchError())
+	expectErr(m.Verify(ctx, "login", "other@example.com", "000001"), errs.GetVerifyCodeMismatchError())
+	expectErr(m.Verify(ctx, "login", "other@example.com", "222222"), errs.GetVerifyCodeTooManyAttemptsError())
+
+	short := NewVerifyCodeManager(store, WithCodeTTL(time.Millisecond), WithResendCooldown(0))
+	key := short.key("login", "late@example.com")
+	if _, _, err := store.Issue(ctx, key, short.hash(key, "123456"), short.ttl, short.cooldown); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	expectErr(short.Verify(ctx, "login", "late@example.com", "123456"), errs.GetVerifyCodeExpiredError())
+}
+
+func TestRedisVerifyCodeStore(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	store := NewRedisVerifyCodeStore(client)
+
+	if ok, _, err := store.Issue(ctx, "code:a", "h1", time.Minute, 30*time.Second); err != nil || !ok {
+		t.Fatalf("expected issue, got %v %v", ok, err)
+	}
+	// 冷却期内返回剩余时间
+	mr.FastForward(10 * time.Second)
+	if ok, wait, err := store.Issue(ctx, "code:a", "h2", time.Minute, 30*time.Second); err != nil || ok || wait != 20*time.Second {
+		t.Fatalf("expected cooldown with 20s left, got %v %v %v", ok, wait, err)
+	}
+
+	expect := func(key, hash string, expected VerifyCodeResult) {
+		t.Helper()
+		if res, err := store.Verify(ctx, key, hash, 2); err != nil || res != expected {
+			t.Fatalf("%s %s: expected %v, got %v %v", key, hash, expected, res, err)
+		}
+	}
+	expect("code:a", "h2", VerifyCodeMismatch)
+	expect("code:a", "h1", VerifyCodeOK)
+	expect("code:a", "h1", VerifyCodeExpired)
+
+	// 冷却结束后重新发放，尝试次数重置
+	mr.FastForward(20 * time.Second)
+	if ok, _, err := store.Issue(ctx, "code:a", "h3", time.Minute, 30*time.Second); err != nil || !ok {
+		t.Fatalf("expected issue after cooldown, got %v %v", ok, err)
+	}
+	expect("code:a", "x", VerifyCodeMismatch)
+	expect("code:a", "x", VerifyCodeMismatch)
+	expect("code:a", "h3", VerifyCodeTooManyAttempts)
+
+	if _, _, err := store.Issue(ctx, "code:b", "h1", time.Minute, 0); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(time.Minute)
+	expect("code:b", "h1", VerifyCodeExpired)
+
+	if err := store.Clear(ctx, "code:a"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("code:a") || mr.Exists("code:a:cooldown") {
+		t.Fatal("expected code and cooldown to be cleared")
+	}
+}
+
+func TestVerifyCodeSend(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryVerifyCodeStore()
+	m := NewVerifyCodeManager(store, WithAlphanumericCode(), WithCodeLength(8))
+
+	code, err := m.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 8 || strings.Trim(code, alphanumericCodeCharset) != "" {
+		t.Fatalf("unexpected code %q", code)
+	}
+
+	if err = m.Send(ctx, GetNewEmail(nil, nil, "", "", "", "", ""), "login"); !errors.Is(err, ErrVerifyCodeRecipient) {
+		t.Fatalf("expected ErrVerifyCodeRecipient, got %v", err)
+	}
+	// 发送失败时清除冷却状态
+	e := GetNewEmail([]string{"user@example.com"}, nil, "", "", "", "", "")
+	if err = m.Send(ctx, e, "login"); !errors.Is(err, ErrEmailTemplatesNotSet) {
+		t.Fatalf("expected ErrEmailTemplatesNotSet, got %v", err)
+	}
+	key := m.key("login", "user@example.com")
+	if ok, _, _ := store.Issue(ctx, key, "x", m.ttl, m.cooldown); !ok {
+		t.Fatal("cooldown should be cleared after a failed send")
+	}
+
+	e.CodeFromUser = "x"
+	var errRsp *errs.ErrRsp
+	if err = e.VerifyCode(ctx, m, "login"); !errors.As(err, &errRsp) || errRsp.Code != errs.VerifyCodeMismatchCode {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+}
+
+func TestVerifyCodeSendAndVerify(t *testing.T) {
+	ctx := context.Background()
+	mailer := NewMemoryMailer()
+	templates, err := NewEmailTemplates(fstest.MapFS{
+		"verify_code.txt": {Data: []byte(`{{.Code}}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewVerifyCodeManager(NewMemoryVerifyCodeStore())
+	e := GetNewEmail([]string{"user@example.com"}, nil, "from@example.com", "", "", "", "",
+		WithEmailTemplates(templates), WithMailer(mailer))
+
+	if err = m.Send(ctx, e, "login"); err != nil {
+		t.Fatal(err)
+	}
+	var errRsp *errs.ErrRsp
+	if err = m.Send(ctx, e, "login"); !errors.As(err, &errRsp) || errRsp.Code != errs.VerifyCodeResendTooSoonCode {
+		t.Fatalf("expected resend cooldown, got %v", err)
+	}
+	if errRsp.RetryAfter < 59 || errRsp.RetryAfter > 60 {
+		t.Fatalf("expected retry after the remaining cooldown, got %d", errRsp.RetryAfter)
+	}
+
+	e.CodeFromUser = string(mailer.Last().Text)
+	if err = e.VerifyCode(ctx, m, "login"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyCodeDisplayNameAddress(t *testing.T) {
+	ctx := context.Background()
+	mailer := NewMemoryMailer()
+	templates, err := NewEmailTemplates(fstest.MapFS{
+		"verify_code.txt": {Data: []byte(`{{.Code}}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewVerifyCodeManager(NewMemoryVerifyCodeStore())
+	e := GetNewEmail([]string{"Alice <A@x.com>"}, nil, "from@example.com", "", "", "", "",
+		WithEmailTemplates(templates), WithMailer(mailer))
+	if err = m.Send(ctx, e, "login"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Verify(ctx, "login", "a@x.com", string(mailer.Last().Text)); err != nil {
+		t.Fatal(err)
+	}
+}
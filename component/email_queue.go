@@ -0,0 +1,342 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jordan-wright/email"
+	"github.com/nioliu/commons/log"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrEmailQueueFull   = errors.New("email queue is full")
+	ErrEmailQueueClosed = errors.New("email queue is closed")
+)
+
+const (
+	defaultEmailQueueSize    = 1000
+	defaultEmailQueueWorkers = 2
+	defaultEmailSendAttempts = 5
+	defaultEmailRetryBase    = time.Second
+	defaultEmailRetryMax     = time.Minute
+	defaultEmailSendTimeout  = 30 * time.Second
+	emailRetryJitterFraction = 0.2
+)
+
+// EmailQueue 后台发送邮件，本身也实现了 Mailer，通过 WithMailer 设置给 EmailVerifier 后发送不再阻塞请求；
+// 失败时按指数退避重试，超过最大次数后交给死信处理
+type EmailQueue struct {
+	mailer      Mailer
+	size        int
+	workers     int
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	sendTimeout time.Duration
+	deadLetter  func(msg *email.Email, err error)
+
+	queue    chan *queuedEmail
+	stop     chan struct{}
+	wg       sync.WaitGroup // 工作协程
+	inflight sync.WaitGroup // 已经入队但还没有成功或者进入死信的邮件
+	mu       sync.RWMutex   // 保护closed，保证Close之后不会再有inflight.Add
+	closed   bool
+	aborted  atomic.Bool // Close超时，剩下的邮件直接进入死信
+
+	retryMu  sync.Mutex
+	retrying map[*queuedEmail]*time.Timer // 等待重试的邮件
+
+	stats struct {
+		sent, retried, dead atomic.Int64
+	}
+}
+
+type queuedEmail struct {
+	msg      *email.Email
+	attempts int
+}
+
+// EmailQueueStats 发送统计
+type EmailQueueStats struct {
+	Sent    int64 `json:"sent"`
+	Retried int64 `json:"retried"`
+	Dead    int64 `json:"dead"`
+	Pending int   `json:"pending"` // 队列中等待发送的数量，不包括等待重试的
+}
+
+type EmailQueueOption func(q *EmailQueue)
+
+// WithQueueSize 队列长度，队列满时 Send 返回ErrEmailQueueFull，默认1000
+func WithQueueSize(size int) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.size = size
+	}
+}
+
+// WithQueueWorkers 发送协程数，默认2
+func WithQueueWorkers(n int) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.workers = n
+	}
+}
+
+// WithMaxSendAttempts 每封邮件最多发送几次，默认5次；SMTP返回5xx的永久错误时不重试
+func WithMaxSendAttempts(n int) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.maxAttempts = n
+	}
+}
+
+// WithRetryBackoff 第n次重试前等待 base*2^(n-1)，不超过max，并加上20%的随机抖动，默认1s、1min
+func WithRetryBackoff(base, max time.Duration) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.retryBase = base
+		q.retryMax = max
+	}
+}
+
+// WithSendTimeout 单次发送的超时时间，默认30s
+func WithSendTimeout(timeout time.Duration) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.sendTimeout = timeout
+	}
+}
+
+// WithDeadLetter 重试次数用尽或者关闭超时的邮件交给fn处理，默认打印错误日志
+func WithDeadLetter(fn func(msg *email.Email, err error)) EmailQueueOption {
+	return func(q *EmailQueue) {
+		q.deadLetter = fn
+	}
+}
+
+// NewEmailQueue 创建后立即启动发送协程，退出前需要调用 Close
+func NewEmailQueue(mailer Mailer, opts ...EmailQueueOption) *EmailQueue {
+	q := &EmailQueue{
+		mailer:      mailer,
+		size:        defaultEmailQueueSize,
+		workers:     defaultEmailQueueWorkers,
+		maxAttempts: defaultEmailSendAttempts,
+		retryBase:   defaultEmailRetryBase,
+		retryMax:    defaultEmailRetryMax,
+		sendTimeout: defaultEmailSendTimeout,
+		deadLetter:  logDeadLetter,
+		stop:        make(chan struct{}),
+		retrying:    make(map[*queuedEmail]*time.Timer),
+	}
+	for _, o := range opts {
+		o(q)
+	}
+	q.queue = make(chan *queuedEmail, q.size)
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Send 复制一份邮件放入队列后立即返回，不会等待发送完成
+func (q *EmailQueue) Send(ctx context.Context, msg *email.Email) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrEmailQueueClosed
+	}
+
+	q.inflight.Add(1)
+	select {
+	case q.queue <- &queuedEmail{msg: copyEmail(msg)}:
+		return nil
+	default:
+		q.inflight.Done()
+		return ErrEmailQueueFull
+	}
+}
+
+// Close 不再接收新邮件，等待队列中和等待重试的邮件发送完；ctx结束时剩下的邮件全部进入死信，
+// 正在发送的邮件会等到发送结束，Close返回之后不会再调用死信处理
+func (q *EmailQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		q.aborted.Store(true)
+		q.abortRetries()
+	}
+	close(q.stop)
+	q.wg.Wait()
+
+	// 工作协程已经退出，队列中剩下的和已经触发的重试直接进入死信，全部处理完才返回
+	for {
+		select {
+		case item := <-q.queue:
+			q.dead(item, ErrEmailQueueClosed)
+		case <-done:
+			return err
+		}
+	}
+}
+
+func (q *EmailQueue) Stats() EmailQueueStats {
+	return EmailQueueStats{
+		Sent:    q.stats.sent.Load(),
+		Retried: q.stats.retried.Load(),
+		Dead:    q.stats.dead.Load(),
+		Pending: len(q.queue),
+	}
+}
+
+func (q *EmailQueue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case item := <-q.queue:
+			q.deliver(item)
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+func (q *EmailQueue) deliver(item *queuedEmail) {
+	if q.aborted.Load() {
+		q.dead(item, ErrEmailQueueClosed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.sendTimeout)
+	err := q.mailer.Send(ctx, item.msg)
+	cancel()
+	if err == nil {
+		q.stats.sent.Add(1)
+		q.inflight.Done()
+		return
+	}
+
+	item.attempts++
+	if item.attempts >= q.maxAttempts || isPermanentSMTPError(err) {
+		q.dead(item, err)
+		return
+	}
+	q.retryMu.Lock()
+	defer q.retryMu.Unlock()
+	// 和 abortRetries 持有同一把锁，Close超时之后不会再加入新的重试
+	if q.aborted.Load() {
+		q.dead(item, err)
+		return
+	}
+	q.stats.retried.Add(1)
+	q.retrying[item] = time.AfterFunc(q.backoff(item.attempts), func() {
+		q.retryMu.Lock()
+		delete(q.retrying, item)
+		q.retryMu.Unlock()
+		q.requeue(item)
+	})
+}
+
+// isPermanentSMTPError 5xx是永久错误，比如收件人不存在，重试也不会成功
+func isPermanentSMTPError(err error) bool {
+	var smtpErr *textproto.Error
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500
+}
+
+// abortRetries 取消还没有到时间的重试，直接进入死信
+func (q *EmailQueue) abortRetries() {
+	q.retryMu.Lock()
+	defer q.retryMu.Unlock()
+	for item, timer := range q.retrying {
+		if timer.Stop() {
+			delete(q.retrying, item)
+			q.dead(item, ErrEmailQueueClosed)
+		}
+	}
+}
+
+// requeue 重试时队列满了就等待，不会因为新邮件太多丢掉已经接收的邮件
+func (q *EmailQueue) requeue(item *queuedEmail) {
+	if q.aborted.Load() {
+		q.dead(item, ErrEmailQueueClosed)
+		return
+	}
+	select {
+	case q.queue <- item:
+	case <-q.stop:
+		q.dead(item, ErrEmailQueueClosed)
+	}
+}
+
+func (q *EmailQueue) backoff(attempts int) time.Duration {
+	d := q.retryBase
+	for i := 1; i < attempts && d < q.retryMax; i++ {
+		d *= 2
+	}
+	if d > q.retryMax {
+		d = q.retryMax
+	}
+	jitter := time.Duration(rand.Float64() * emailRetryJitterFraction * float64(d))
+	return d + jitter
+}
+
+func (q *EmailQueue) dead(item *queuedEmail, err error) {
+	q.stats.dead.Add(1)
+	q.deadLetter(item.msg, err)
+	q.inflight.Done()
+}
+
+func logDeadLetter(msg *email.Email, err error) {
+	log.ErrorWithCtxFields(context.Background(), "send email failed, dropped",
+		zap.Strings("to", msg.To), zap.String("subject", msg.Subject), zap.Error(err))
+}
+
+// copyEmail EmailVerifier 会复用同一个email.Email，入队和记录时需要深拷贝
+func copyEmail(msg *email.Email) *email.Email {
+	cp := *msg
+	cp.ReplyTo = append([]string(nil), msg.ReplyTo...)
+	cp.To = append([]string(nil), msg.To...)
+	cp.Bcc = append([]string(nil), msg.Bcc...)
+	cp.Cc = append([]string(nil), msg.Cc...)
+	cp.ReadReceipt = append([]string(nil), msg.ReadReceipt...)
+	cp.Text = append([]byte(nil), msg.Text...)
+	cp.HTML = append([]byte(nil), msg.HTML...)
+	cp.Headers = copyHeader(msg.Headers)
+	cp.Attachments = nil
+	for _, a := range msg.Attachments {
+		attachment := *a
+		attachment.Header = copyHeader(a.Header)
+		attachment.Content = append([]byte(nil), a.Content...)
+		cp.Attachments = append(cp.Attachments, &attachment)
+	}
+	return &cp
+}
+
+func copyHeader(header textproto.MIMEHeader) textproto.MIMEHeader {
+	if header == nil {
+		return nil
+	}
+	cp := make(textproto.MIMEHeader, len(header))
+	for k, v := range header {
+		cp[k] = append([]string(nil), v...)
+	}
+	return cp
+}
@@ -0,0 +1,179 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jordan-wright/email"
+)
+
+// flakyMailer 前failures次发送失败
+type flakyMailer struct {
+	failures atomic.Int32
+	MemoryMailer
+}
+
+func (m *flakyMailer) Send(ctx context.Context, msg *email.Email) error {
+	if m.failures.Add(-1) >= 0 {
+		return errors.New("temporary failure")
+	}
+	return m.MemoryMailer.Send(ctx, msg)
+}
+
+// rejectingMailer 服务器拒收，返回5xx
+type rejectingMailer struct {
+	calls atomic.Int32
+}
+
+func (m *rejectingMailer) Send(ctx context.Context, msg *email.Email) error {
+	m.calls.Add(1)
+	return fmt.Errorf("send failed: %w", &textproto.Error{Code: 550, Msg: "mailbox unavailable"})
+}
+
+// blockingMailer 收到release之前阻塞，之后返回临时错误
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg *email.Email) error {
+	close(m.started)
+	<-m.release
+	return errors.New("temporary failure")
+}
+
+func TestEmailQueuePermanentError(t *testing.T) {
+	mailer := &rejectingMailer{}
+	var dead atomic.Int32
+	q := NewEmailQueue(mailer, WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithDeadLetter(func(*email.Email, error) { dead.Add(1) }))
+	if err := q.Send(context.Background(), &email.Email{To: []string{"to@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if mailer.calls.Load() != 1 || dead.Load() != 1 || q.Stats().Retried != 0 {
+		t.Fatalf("expected permanent error to skip retries, got %d calls %+v", mailer.calls.Load(), q.Stats())
+	}
+}
+
+func TestEmailQueueRetry(t *testing.T) {
+	mailer := &flakyMailer{}
+	mailer.failures.Store(2)
+	q := NewEmailQueue(mailer, WithRetryBackoff(time.Millisecond, 5*time.Millisecond))
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "", WithMailer(q))
+
+	for i := 0; i < 3; i++ {
+		if err := e.SendContentEmail("subject", []byte{byte('a' + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(mailer.Messages()); n != 3 {
+		t.Fatalf("expected 3 messages after flush, got %d", n)
+	}
+	if stats := q.Stats(); stats.Sent != 3 || stats.Retried != 2 || stats.Dead != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if err := e.SendContentEmail("subject", nil); !errors.Is(err, ErrEmailQueueClosed) {
+		t.Fatalf("expected ErrEmailQueueClosed, got %v", err)
+	}
+}
+
+func TestEmailQueueDeadLetter(t *testing.T) {
+	mailer := &flakyMailer{}
+	mailer.failures.Store(1 << 30)
+	var mu sync.Mutex
+	var dead []error
+	q := NewEmailQueue(mailer, WithMaxSendAttempts(3), WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithDeadLetter(func(msg *email.Email, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dead = append(dead, err)
+		}))
+
+	if err := q.Send(context.Background(), &email.Email{To: []string{"to@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || q.Stats().Retried != 2 {
+		t.Fatalf("expected one dead letter after 3 attempts, got %v %+v", dead, q.Stats())
+	}
+
+	// 关闭超时，等待重试的邮件进入死信
+	var aborted atomic.Int32
+	slow := NewEmailQueue(mailer, WithRetryBackoff(time.Hour, time.Hour), WithQueueSize(1),
+		WithDeadLetter(func(*email.Email, error) { aborted.Add(1) }))
+	if err := slow.Send(context.Background(), &email.Email{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := slow.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if aborted.Load() != 1 {
+		t.Fatalf("expected pending retry to be dead-lettered, got %d", aborted.Load())
+	}
+}
+
+func TestEmailQueueCloseWaitsForSending(t *testing.T) {
+	mailer := &blockingMailer{started: make(chan struct{}), release: make(chan struct{})}
+	var dead atomic.Int32
+	q := NewEmailQueue(mailer, WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithDeadLetter(func(*email.Email, error) { dead.Add(1) }))
+	if err := q.Send(context.Background(), &email.Email{}); err != nil {
+		t.Fatal(err)
+	}
+	<-mailer.started
+	time.AfterFunc(50*time.Millisecond, func() { close(mailer.release) })
+
+	// 关闭超时时邮件正在发送，发送失败后不能再安排重试，Close返回前已经进入死信
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if dead.Load() != 1 || q.Stats().Retried != 0 {
+		t.Fatalf("expected the failed send to be dead-lettered before Close returns, got %d %+v", dead.Load(), q.Stats())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if dead.Load() != 1 {
+		t.Fatalf("dead letter called after Close returned, got %d", dead.Load())
+	}
+}
+
+func TestCopyEmail(t *testing.T) {
+	msg := &email.Email{
+		To:          []string{"to@example.com"},
+		Headers:     textproto.MIMEHeader{"X-Tag": {"a"}},
+		Attachments: []*email.Attachment{{Filename: "a.txt", Header: textproto.MIMEHeader{}, Content: []byte("a")}},
+	}
+	cp := copyEmail(msg)
+	msg.To[0] = "other@example.com"
+	msg.Headers["X-Tag"][0] = "b"
+	msg.Headers.Set("X-Other", "b")
+	msg.Attachments[0].Filename = "b.txt"
+	msg.Attachments[0].Header.Set("X-Other", "b")
+	msg.Attachments[0].Content[0] = 'b'
+
+	if cp.To[0] != "to@example.com" || cp.Headers.Get("X-Tag") != "a" || cp.Headers.Get("X-Other") != "" {
+		t.Fatalf("copy shares recipients or headers: %+v", cp)
+	}
+	a := cp.Attachments[0]
+	if a.Filename != "a.txt" || a.Header.Get("X-Other") != "" || string(a.Content) != "a" {
+		t.Fatalf("copy shares attachments: %+v", a)
+	}
+}
@@ -0,0 +1,322 @@
+package component
+
+import (
+	"context"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nioliu/commons/errs"
+)
+
+const defaultRateLimitKeyPrefix = "email_rate:"
+
+// RateLimit 键在Window内最多允许Limit次
+type RateLimit struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitRule 某个维度的限制，同一个维度可以同时有多个窗口，比如每分钟1次并且每小时5次
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitStore 滑动窗口计数，多副本部署时使用 RedisRateLimitStore
+type RateLimitStore interface {
+	// Allow 所有限制都满足时各记一次并返回true；否则都不记，返回false和最长需要等待的时间
+	Allow(ctx context.Context, now time.Time, limits []RateLimit) (bool, time.Duration, error)
+	// Release 撤销Allow在now时刻记下的一次，用于发送失败时归还额度
+	Release(ctx context.Context, now time.Time, limits []RateLimit) error
+}
+
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local wait = 0
+for i, key in ipairs(KEYS) do
+	local limit = tonumber(ARGV[2 * i + 1])
+	local window = tonumber(ARGV[2 * i + 2])
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	if redis.call("ZCARD", key) >= limit then
+		local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+		local w = tonumber(oldest[2]) + window - now
+		if w > wait then
+			wait = w
+		end
+	end
+end
+if wait > 0 then
+	return wait
+end
+for i, key in ipairs(KEYS) do
+	redis.call("ZADD", key, now, ARGV[2])
+	redis.call("PEXPIRE", key, ARGV[2 * i + 2])
+end
+return 0`)
+
+var releaseRateLimitScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local member = redis.call("ZRANGEBYSCORE", key, ARGV[1], ARGV[1], "LIMIT", 0, 1)[1]
+	if member then
+		redis.call("ZREM", key, member)
+	end
+end
+return 0`)
+
+// RedisRateLimitStore 每个键一个有序集合，记录窗口内每次请求的时间，通过脚本原子地检查和记录；
+// Redis Cluster中所有键需要在同一个slot，可以在前缀中使用 {hash tag}
+type RedisRateLimitStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisRateLimitStore(client redis.Cmdable) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+func (s *RedisRateLimitStore) Allow(ctx context.Context, now time.Time, limits []RateLimit) (bool, time.Duration, error) {
+	if len(limits) == 0 {
+		return true, 0, nil
+	}
+	member, err := NewULID()
+	if err != nil {
+		return false, 0, err
+	}
+	keys := make([]string, len(limits))
+	args := make([]interface{}, 0, 2+2*len(limits))
+	args = append(args, now.UnixMilli(), member.String())
+	for i, l := range limits {
+		keys[i] = l.Key
+		args = append(args, l.Limit, l.Window.Milliseconds())
+	}
+	wait, err := rateLimitScript.Run(ctx, s.client, keys, args...).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	return wait == 0, time.Duration(wait) * time.Millisecond, nil
+}
+
+func (s *RedisRateLimitStore) Release(ctx context.Context, now time.Time, limits []RateLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+	keys := make([]string, len(limits))
+	for i, l := range limits {
+		keys[i] = l.Key
+	}
+	return releaseRateLimitScript.Run(ctx, s.client, keys, now.UnixMilli()).Err()
+}
+
+// MemoryRateLimitStore 每个键保存窗口内的请求时间，只能限制当前进程的发送量
+type MemoryRateLimitStore struct {
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{events: make(map[string][]time.Time)}
+}
+
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, now time.Time, limits []RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wait time.Duration
+	for _, l := range limits {
+		events := s.events[l.Key]
+		i := 0
+		for i < len(events) && !events[i].After(now.Add(-l.Window)) {
+			i++
+		}
+		events = events[i:]
+		if len(events) == 0 {
+			delete(s.events, l.Key)
+		} else {
+			s.events[l.Key] = events
+		}
+		if len(events) >= l.Limit {
+			if w := events[0].Add(l.Window).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return false, wait, nil
+	}
+	for _, l := range limits {
+		s.events[l.Key] = append(s.events[l.Key], now)
+	}
+	return true, 0, nil
+}
+
+func (s *MemoryRateLimitStore) Release(ctx context.Context, now time.Time, limits []RateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range limits {
+		events := s.events[l.Key]
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Equal(now) {
+				events = append(events[:i], events[i+1:]...)
+				break
+			}
+		}
+		if len(events) == 0 {
+			delete(s.events, l.Key)
+		} else {
+			s.events[l.Key] = events
+		}
+	}
+	return nil
+}
+
+// EmailRateLimiter 按收件人地址、收件人域名和调用方限制发送频率，防止验证码接口被用来轰炸邮箱
+type EmailRateLimiter struct {
+	store     RateLimitStore
+	prefix    string
+	recipient []RateLimitRule
+	domain    []RateLimitRule
+	caller    []RateLimitRule
+	clock     Clock
+}
+
+type EmailRateLimitOption func(l *EmailRateLimiter)
+
+// WithRecipientLimits 每个收件人地址的限制，默认每分钟1次、每小时5次；不传参数时不限制
+func WithRecipientLimits(rules ...RateLimitRule) EmailRateLimitOption {
+	return func(l *EmailRateLimiter) {
+		l.recipient = rules
+	}
+}
+
+// WithDomainLimits 每个收件人域名的限制，默认每分钟100次
+func WithDomainLimits(rules ...RateLimitRule) EmailRateLimitOption {
+	return func(l *EmailRateLimiter) {
+		l.domain = rules
+	}
+}
+
+// WithCallerLimits 每个调用方的限制，调用方通过 WithRateLimitCaller 放在ctx中，默认每小时20次
+func WithCallerLimits(rules ...RateLimitRule) EmailRateLimitOption {
+	return func(l *EmailRateLimiter) {
+		l.caller = rules
+	}
+}
+
+// WithRateLimitKeyPrefix 存储键的前缀，默认 "email_rate:"
+func WithRateLimitKeyPrefix(prefix string) EmailRateLimitOption {
+	return func(l *EmailRateLimiter) {
+		l.prefix = prefix
+	}
+}
+
+// WithRateLimitClock 测试时替换时钟
+func WithRateLimitClock(clock Clock) EmailRateLimitOption {
+	return func(l *EmailRateLimiter) {
+		l.clock = clock
+	}
+}
+
+func NewEmailRateLimiter(store RateLimitStore, opts ...EmailRateLimitOption) *EmailRateLimiter {
+	l := &EmailRateLimiter{
+		store:     store,
+		prefix:    defaultRateLimitKeyPrefix,
+		recipient: []RateLimitRule{{Limit: 1, Window: time.Minute}, {Limit: 5, Window: time.Hour}},
+		domain:    []RateLimitRule{{Limit: 100, Window: time.Minute}},
+		caller:    []RateLimitRule{{Limit: 20, Window: time.Hour}},
+		clock:     SystemClock,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// normalizeAddress 去掉显示名并转为小写，"Alice <A@x.com>" 和 "a@x.com" 视为同一个地址
+func normalizeAddress(address string) string {
+	if addr, err := mail.ParseAddress(address); err == nil {
+		return strings.ToLower(addr.Address)
+	}
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// Allow 检查并记录一次发送，caller为空时不检查调用方；超过限制时返回 errs.GetTooManyRequestsError
+func (l *EmailRateLimiter) Allow(ctx context.Context, caller string, recipients []string) error {
+	_, err := l.Acquire(ctx, caller, recipients)
+	return err
+}
+
+// Acquire 与 Allow 相同，另外返回归还这次额度的release，发送失败时调用，不会因为SMTP出错占用收件人的额度
+func (l *EmailRateLimiter) Acquire(ctx context.Context, caller string, recipients []string) (release func(), err error) {
+	limits := l.limits(caller, recipients)
+	if len(limits) == 0 {
+		return func() {}, nil
+	}
+
+	now := l.clock.Now()
+	ok, wait, err := l.store.Allow(ctx, now, limits)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.GetTooManyRequestsError(wait)
+	}
+	// 发送失败可能是ctx超时，归还时不能再受ctx取消的影响
+	return func() { _ = l.store.Release(context.WithoutCancel(ctx), now, limits) }, nil
+}
+
+func (l *EmailRateLimiter) limits(caller string, recipients []string) []RateLimit {
+	var limits []RateLimit
+	domains := map[string]bool{}
+	for _, r := range recipients {
+		address := normalizeAddress(r)
+		limits = l.appendLimits(limits, "rcpt:"+address, l.recipient)
+		if i := strings.LastIndexByte(address, '@'); i >= 0 && !domains[address[i+1:]] {
+			domains[address[i+1:]] = true
+			limits = l.appendLimits(limits, "domain:"+address[i+1:], l.domain)
+		}
+	}
+	if caller != "" {
+		limits = l.appendLimits(limits, "caller:"+caller, l.caller)
+	}
+	return limits
+}
+
+// appendLimits 同一个维度的多个窗口通过键中的窗口长度区分
+func (l *EmailRateLimiter) appendLimits(limits []RateLimit, key string, rules []RateLimitRule) []RateLimit {
+	for _, r := range rules {
+		if r.Limit <= 0 || r.Window <= 0 {
+			continue
+		}
+		limits = append(limits, RateLimit{
+			Key:    l.prefix + key + ":" + strconv.FormatInt(r.Window.Milliseconds(), 10),
+			Limit:  r.Limit,
+			Window: r.Window,
+		})
+	}
+	return limits
+}
+
+type rateLimitCallerKey struct{}
+
+// WithRateLimitCaller 在ctx中设置调用方，比如客户端IP或者用户ID
+func WithRateLimitCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, rateLimitCallerKey{}, caller)
+}
+
+// RateLimitCallerFromContext 没有设置时返回空字符串
+func RateLimitCallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(rateLimitCallerKey{}).(string)
+	return caller
+}
+
+// WithRateLimiter 发送前按ctx中的调用方和所有收件人检查发送频率，Mailer返回错误时归还额度；
+// Mailer为 EmailQueue 时入队即算发送成功
+func WithRateLimiter(limiter *EmailRateLimiter) EmailOption {
+	return func(e *EmailVerifier) {
+		e.rateLimiter = limiter
+	}
+}
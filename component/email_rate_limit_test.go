@@ -0,0 +1,160 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/nioliu/commons/errs"
+)
+
+func TestEmailRateLimiter(t *testing.T) {
+	clock := newManualClock()
+	limiter := NewEmailRateLimiter(NewMemoryRateLimitStore(), WithRateLimitClock(clock),
+		WithRecipientLimits(RateLimitRule{Limit: 1, Window: time.Minute}, RateLimitRule{Limit: 2, Window: time.Hour}),
+		WithDomainLimits(RateLimitRule{Limit: 2, Window: time.Minute}),
+		WithCallerLimits(RateLimitRule{Limit: 10, Window: time.Hour}))
+	mailer := NewMemoryMailer()
+	ctx := WithRateLimitCaller(context.Background(), "10.0.0.1")
+	send := func(to string) error {
+		e := GetNewEmail([]string{to}, nil, "from@example.com", "", "", "", "",
+			WithMailer(mailer), WithRateLimiter(limiter))
+		return e.send(ctx)
+	}
+	expectLimited := func(err error, retryAfter int64) {
+		t.Helper()
+		var rsp *errs.ErrRsp
+		if !errors.As(err, &rsp) || rsp.Code != errs.TooManyRequestsCode {
+			t.Fatalf("expected too many requests, got %v", err)
+		}
+		if rsp.RetryAfter != retryAfter {
+			t.Fatalf("expected retry after %d, got %d", retryAfter, rsp.RetryAfter)
+		}
+	}
+
+	if err := send("a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// 同一个地址一分钟内只能发一次，大小写不同也是同一个地址
+	clock.Add(20 * time.Second)
+	expectLimited(send("A@Example.com"), 40)
+
+	clock.Add(40 * time.Second)
+	if err := send("a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// 每小时2次用完，需要等到第一封发出后的一小时
+	clock.Add(time.Minute)
+	expectLimited(send("a@example.com"), int64((58 * time.Minute).Seconds()))
+
+	// 同一域名每分钟2次，一分钟前发给a的不算
+	if err := send("b@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("c@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	expectLimited(send("d@example.com"), 60)
+	if err := send("d@other.com"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mailer.Messages()); n != 5 {
+		t.Fatalf("expected 5 messages, got %d", n)
+	}
+}
+
+func TestEmailRateLimiterReleaseOnFailure(t *testing.T) {
+	clock := newManualClock()
+	limiter := NewEmailRateLimiter(NewMemoryRateLimitStore(), WithRateLimitClock(clock),
+		WithRecipientLimits(RateLimitRule{Limit: 1, Window: time.Minute}))
+	ctx := context.Background()
+
+	failed := GetNewEmail([]string{"a@example.com"}, nil, "from@example.com", "", "", "", "",
+		WithMailer(&rejectingMailer{}), WithRateLimiter(limiter))
+	var smtpErr *textproto.Error
+	if err := failed.send(ctx); !errors.As(err, &smtpErr) {
+		t.Fatalf("expected smtp error, got %v", err)
+	}
+	// 发送失败不占用额度
+	mailer := NewMemoryMailer()
+	e := GetNewEmail([]string{"a@example.com"}, nil, "from@example.com", "", "", "", "",
+		WithMailer(mailer), WithRateLimiter(limiter))
+	if err := e.send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.send(ctx); err == nil {
+		t.Fatal("expected successful send to use up the quota")
+	}
+}
+
+func TestMemoryRateLimitStoreCaller(t *testing.T) {
+	clock := newManualClock()
+	limiter := NewEmailRateLimiter(NewMemoryRateLimitStore(), WithRateLimitClock(clock),
+		WithRecipientLimits(), WithDomainLimits(), WithCallerLimits(RateLimitRule{Limit: 2, Window: time.Minute}))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(ctx, "user-1", []string{"a@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		clock.Add(time.Second)
+	}
+	if err := limiter.Allow(ctx, "user-1", []string{"b@example.com"}); err == nil {
+		t.Fatal("expected caller to be limited")
+	}
+	// 被拒绝的请求不计数，其他调用方和没有调用方时不受影响
+	if err := limiter.Allow(ctx, "user-2", []string{"a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Allow(ctx, "", []string{"a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(58 * time.Second)
+	if err := limiter.Allow(ctx, "user-1", []string{"a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	store := NewRedisRateLimitStore(client)
+	now := time.UnixMilli(1_700_000_000_000)
+	limits := []RateLimit{
+		{Key: "rate:to:a", Limit: 1, Window: time.Minute},
+		{Key: "rate:caller:x", Limit: 2, Window: time.Hour},
+	}
+
+	if ok, _, err := store.Allow(ctx, now, limits); err != nil || !ok {
+		t.Fatalf("expected allow, got %v %v", ok, err)
+	}
+	if ttl := mr.TTL("rate:caller:x"); ttl != time.Hour {
+		t.Fatalf("expected key to expire with its window, got %v", ttl)
+	}
+	// 超过任意一个限制时都不计数，返回最长的等待时间
+	if ok, wait, err := store.Allow(ctx, now.Add(10*time.Second), limits); err != nil || ok || wait != 50*time.Second {
+		t.Fatalf("expected 50s wait, got %v %v %v", ok, wait, err)
+	}
+	if n, _ := client.ZCard(ctx, "rate:caller:x").Result(); n != 1 {
+		t.Fatalf("rejected request was counted, got %d", n)
+	}
+
+	if ok, _, err := store.Allow(ctx, now.Add(time.Minute), limits); err != nil || !ok {
+		t.Fatalf("expected allow after window, got %v %v", ok, err)
+	}
+	if ok, wait, err := store.Allow(ctx, now.Add(2*time.Minute), limits); err != nil || ok || wait != 58*time.Minute {
+		t.Fatalf("expected caller limit with 58m wait, got %v %v %v", ok, wait, err)
+	}
+
+	// 归还一分钟时记下的一次后，调用方的额度可以再用一次
+	if err := store.Release(ctx, now.Add(time.Minute), limits); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := client.ZCard(ctx, "rate:caller:x").Result(); n != 1 {
+		t.Fatalf("expected one entry after release, got %d", n)
+	}
+	if ok, _, err := store.Allow(ctx, now.Add(2*time.Minute), limits); err != nil || !ok {
+		t.Fatalf("expected allow after release, got %v %v", ok, err)
+	}
+}
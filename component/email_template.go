@@ -0,0 +1,197 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+var ErrEmailTemplateNotFound = errors.New("email template not found")
+
+const (
+	htmlTemplateExt    = ".html"
+	textTemplateExt    = ".txt"
+	subjectTemplateExt = ".subject"
+)
+
+// EmailTemplates 按名称和地区组织的邮件模板，文件命名为 <name>[.<locale>].<html|txt|subject>，比如
+//
+//	verify_code.html         默认的HTML正文
+//	verify_code.txt          默认的纯文本正文
+//	verify_code.subject      默认的标题
+//	verify_code.zh-CN.html   zh-CN 地区的HTML正文
+//
+// HTML使用html/template渲染，会对数据做转义；纯文本和标题使用text/template渲染
+type EmailTemplates struct {
+	defaultLocale string
+	funcs         map[string]interface{}
+
+	html    map[string]*htmltemplate.Template // key为 name 或 name.locale
+	text    map[string]*texttemplate.Template
+	subject map[string]*texttemplate.Template
+}
+
+type EmailTemplateOption func(t *EmailTemplates)
+
+// WithDefaultLocale 找不到请求地区的模板时使用的地区，再找不到时使用不带地区的模板
+func WithDefaultLocale(locale string) EmailTemplateOption {
+	return func(t *EmailTemplates) {
+		t.defaultLocale = normalizeLocale(locale)
+	}
+}
+
+// WithTemplateFuncs 模板中可以使用的函数
+func WithTemplateFuncs(funcs map[string]interface{}) EmailTemplateOption {
+	return func(t *EmailTemplates) {
+		t.funcs = funcs
+	}
+}
+
+// NewEmailTemplates 从fs.FS加载模板，可以是embed.FS，也可以是os.DirFS
+func NewEmailTemplates(fsys fs.FS, opts ...EmailTemplateOption) (*EmailTemplates, error) {
+	t := &EmailTemplates{
+		html:    make(map[string]*htmltemplate.Template),
+		text:    make(map[string]*texttemplate.Template),
+		subject: make(map[string]*texttemplate.Template),
+	}
+	for _, o := range opts {
+		o(t)
+	}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := path.Ext(p)
+		if ext != htmlTemplateExt && ext != textTemplateExt && ext != subjectTemplateExt {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		key := templateKey(strings.TrimSuffix(path.Base(p), ext))
+
+		switch ext {
+		case htmlTemplateExt:
+			tpl, err := htmltemplate.New(p).Funcs(t.funcs).Parse(string(content))
+			if err != nil {
+				return err
+			}
+			t.html[key] = tpl
+		case textTemplateExt, subjectTemplateExt:
+			tpl, err := texttemplate.New(p).Funcs(t.funcs).Parse(string(content))
+			if err != nil {
+				return err
+			}
+			if ext == textTemplateExt {
+				t.text[key] = tpl
+			} else {
+				t.subject[key] = tpl
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load email templates failed: %w", err)
+	}
+	return t, nil
+}
+
+// LoadEmailTemplatesDir 从目录加载模板
+func LoadEmailTemplatesDir(dir string, opts ...EmailTemplateOption) (*EmailTemplates, error) {
+	return NewEmailTemplates(os.DirFS(dir), opts...)
+}
+
+// RenderedEmail 渲染后的邮件内容，HTML和Text同时存在时发送multipart/alternative
+type RenderedEmail struct {
+	Subject string
+	Text    []byte
+	HTML    []byte
+}
+
+// Render 按地区渲染模板，地区的查找顺序为 zh-CN -> zh -> 默认地区 -> 不带地区；HTML和纯文本至少要有一个
+func (t *EmailTemplates) Render(locale, name string, data interface{}) (*RenderedEmail, error) {
+	rendered := &RenderedEmail{}
+	keys := t.candidateKeys(locale, name)
+
+	var buf bytes.Buffer
+	if tpl := lookupTemplate(t.html, keys); tpl != nil {
+		if err := tpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		rendered.HTML = append([]byte(nil), buf.Bytes()...)
+	}
+	if tpl := lookupTemplate(t.text, keys); tpl != nil {
+		buf.Reset()
+		if err := tpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		rendered.Text = append([]byte(nil), buf.Bytes()...)
+	}
+	if rendered.HTML == nil && rendered.Text == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, name)
+	}
+	if tpl := lookupTemplate(t.subject, keys); tpl != nil {
+		buf.Reset()
+		if err := tpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		// 标题不能换行
+		rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+	return rendered, nil
+}
+
+func (t *EmailTemplates) candidateKeys(locale, name string) []string {
+	keys := make([]string, 0, 4)
+	for _, l := range []string{normalizeLocale(locale), t.defaultLocale} {
+		if l == "" {
+			continue
+		}
+		keys = append(keys, name+"."+l)
+		if i := strings.IndexByte(l, '-'); i > 0 {
+			keys = append(keys, name+"."+l[:i])
+		}
+	}
+	return append(keys, name)
+}
+
+func lookupTemplate[T any](templates map[string]*T, keys []string) *T {
+	for _, k := range keys {
+		if tpl, ok := templates[k]; ok {
+			return tpl
+		}
+	}
+	return nil
+}
+
+// templateKey 文件名中的地区部分统一大小写
+func templateKey(base string) string {
+	if i := strings.LastIndexByte(base, '.'); i > 0 {
+		return base[:i] + "." + normalizeLocale(base[i+1:])
+	}
+	return base
+}
+
+// normalizeLocale zh_CN、ZH-cn 统一为 zh-cn
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// LocaleFromContext 从 PreActionForGolang 写入的 region_data 中取地区，
+// 兼容 Accept-Language 形式的 "zh-CN,zh;q=0.9"，只取第一个
+func LocaleFromContext(ctx context.Context) string {
+	region, _ := ctx.Value("region_data").(string)
+	if i := strings.IndexAny(region, ",;"); i >= 0 {
+		region = region[:i]
+	}
+	return normalizeLocale(region)
+}
@@ -0,0 +1,85 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestEmailTemplates(t *testing.T) *EmailTemplates {
+	fsys := fstest.MapFS{
+		"verify_code.html":         {Data: []byte(`<p>Hi {{.Name}}, your code is <b>{{.Code}}</b></p>`)},
+		"verify_code.txt":          {Data: []byte(`Hi {{.Name}}, your code is {{.Code}}`)},
+		"verify_code.subject":      {Data: []byte("Verification code\n")},
+		"zh/verify_code.zh.html":   {Data: []byte(`<p>{{.Name}}，您的验证码是 <b>{{.Code}}</b></p>`)},
+		"zh/verify_code.zh.txt":    {Data: []byte(`{{.Name}}，您的验证码是 {{.Code}}`)},
+		"zh/verify_code.zh-TW.txt": {Data: []byte(`{{.Name}}，您的驗證碼是 {{.Code}}`)},
+		"README.md":                {Data: []byte("ignored")},
+	}
+	templates, err := NewEmailTemplates(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return templates
+}
+
+func TestEmailTemplatesRender(t *testing.T) {
+	templates := newTestEmailTemplates(t)
+	data := map[string]string{"Name": "<Tom>", "Code": "123456"}
+
+	rendered, err := templates.Render("en-US", "verify_code", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != "Verification code" {
+		t.Fatalf("unexpected subject %q", rendered.Subject)
+	}
+	if string(rendered.HTML) != `<p>Hi &lt;Tom&gt;, your code is <b>123456</b></p>` {
+		t.Fatalf("unexpected html %s", rendered.HTML)
+	}
+	if string(rendered.Text) != `Hi <Tom>, your code is 123456` {
+		t.Fatalf("unexpected text %s", rendered.Text)
+	}
+
+	// zh-CN 回退到 zh，标题回退到默认
+	rendered, err = templates.Render("zh_CN", "verify_code", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered.Text) != `<Tom>，您的验证码是 123456` || rendered.Subject != "Verification code" {
+		t.Fatalf("unexpected zh-CN result %q %q", rendered.Text, rendered.Subject)
+	}
+	rendered, err = templates.Render("zh-tw", "verify_code", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered.Text) != `<Tom>，您的驗證碼是 123456` || !bytes.Contains(rendered.HTML, []byte("您的验证码")) {
+		t.Fatalf("unexpected zh-TW result %q %q", rendered.Text, rendered.HTML)
+	}
+
+	if _, err = templates.Render("", "missing", data); !errors.Is(err, ErrEmailTemplateNotFound) {
+		t.Fatalf("expected ErrEmailTemplateNotFound, got %v", err)
+	}
+}
+
+func TestSendTemplateEmailMultipart(t *testing.T) {
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "",
+		WithEmailTemplates(newTestEmailTemplates(t)))
+	ctx := context.WithValue(context.Background(), "region_data", "zh-CN,zh;q=0.9")
+	if err := e.render(ctx, "fallback", "verify_code", map[string]string{"Name": "Tom", "Code": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := e.email.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte("multipart/alternative")) {
+		t.Fatalf("expected multipart/alternative message:\n%s", raw)
+	}
+
+	if err = GetNewEmail(nil, nil, "", "", "", "", "").SendTemplateEmail(ctx, "", "verify_code", nil); !errors.Is(err, ErrEmailTemplatesNotSet) {
+		t.Fatalf("expected ErrEmailTemplatesNotSet, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nioliu/commons/log"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrClockBehindHighWater   = errors.New("clock is behind persisted high-water timestamp")
+	ErrInvalidHighWaterConfig = errors.New("invalid high-water config")
+)
+
+const (
+	defaultHighWaterWindow  = time.Second
+	defaultHighWaterMaxWait = 10 * time.Second
+	highWaterIOTimeout      = 3 * time.Second
+)
+
+// HighWaterStore 持久化已经发放过的最大时间戳（unix毫秒），重启后据此判断时钟是否可用
+type HighWaterStore interface {
+	// Load 读取持久化的时间戳，不存在时返回0
+	Load(ctx context.Context) (int64, error)
+	// Save 写入时间戳，实现需要保证只增不减
+	Save(ctx context.Context, ms int64) error
+}
+
+// FileHighWaterStore 写本地文件，先写临时文件再rename，避免写一半时宕机
+type FileHighWaterStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileHighWaterStore(path string) *FileHighWaterStore {
+	return &FileHighWaterStore{path: path}
+}
+
+func (s *FileHighWaterStore) Load(ctx context.Context) (int64, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func (s *FileHighWaterStore) Save(ctx context.Context, ms int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, err := s.Load(ctx); err == nil && cur >= ms {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(strconv.FormatInt(ms, 10)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+var saveHighWaterScript = redis.NewScript(`
+local cur = tonumber(redis.call("GET", KEYS[1]) or "0")
+if tonumber(ARGV[1]) > cur then
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 1`)
+
+// RedisHighWaterStore 写Redis，多个实例应当使用不同的key，比如带上机器ID
+type RedisHighWaterStore struct {
+	client redis.Cmdable
+	key    string
+}
+
+func NewRedisHighWaterStore(client redis.Cmdable, key string) *RedisHighWaterStore {
+	return &RedisHighWaterStore{client: client, key: key}
+}
+
+func (s *RedisHighWaterStore) Load(ctx context.Context) (int64, error) {
+	ms, err := s.client.Get(ctx, s.key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return ms, err
+}
+
+func (s *RedisHighWaterStore) Save(ctx context.Context, ms int64) error {
+	return saveHighWaterScript.Run(ctx, s.client, []string{s.key}, ms).Err()
+}
+
+// HighWaterConfig 持久化最大时间戳的配置
+type HighWaterConfig struct {
+	Store HighWaterStore // 必填
+	// Window 每次持久化领先当前时间戳多少，窗口内发放ID不需要再写存储，为0时默认1s，否则不能小于1ms
+	Window time.Duration
+	// Wait 启动时时钟没有超过持久化的时间戳则等待，否则直接返回ErrClockBehindHighWater
+	Wait bool
+	// MaxWait 等待的上限，默认10s
+	MaxWait time.Duration
+}
+
+// WithHighWater 启用最大时间戳持久化，防止重启后时钟回拨导致重复发号；
+// 存储写入失败时不会发放超出已持久化范围的ID
+func WithHighWater(cfg HighWaterConfig) GeneratorOption {
+	return func(g *Generator) {
+		g.highWater = newHighWater(cfg)
+	}
+}
+
+var legacyHighWater atomic.Pointer[highWater]
+
+// EnableDefaultHighWater 为 CreateSnowflakeId 等字符串ID函数启用最大时间戳持久化，应在启动时调用一次。
+// 时钟没有超过持久化的时间戳时V2函数返回错误，无法返回错误的函数沿用持久化的时间戳之后的一毫秒
+func EnableDefaultHighWater(cfg HighWaterConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	h := newHighWater(cfg)
+	// 短格式按秒生成序列号，必须等到下一秒才能保证不重复
+	if err := h.restore(0, func(mark int64) int64 {
+		return (mark/1000+1)*1000 - 1
+	}); err != nil {
+		return err
+	}
+	for {
+		last := lastT.Load()
+		if last > h.start || lastT.CompareAndSwap(last, h.start+1) {
+			break
+		}
+	}
+	legacyHighWater.Store(h)
+	return nil
+}
+
+// highWater 持久化的时间戳为unix毫秒，内部的时间戳都是相对纪元的毫秒
+type highWater struct {
+	cfg     HighWaterConfig
+	epochMs int64
+
+	start    int64       // 启动时读到的时间戳，时钟超过它之前不能发号
+	passed   atomic.Bool // 时钟已经超过start
+	reserved atomic.Int64
+	saving   atomic.Bool // 后台正在持久化下一个窗口
+
+	mu sync.Mutex // 串行写存储
+}
+
+func newHighWater(cfg HighWaterConfig) *highWater {
+	if cfg.Window == 0 {
+		cfg.Window = defaultHighWaterWindow
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = defaultHighWaterMaxWait
+	}
+	return &highWater{cfg: cfg}
+}
+
+func (c HighWaterConfig) validate() error {
+	if c.Store == nil {
+		return fmt.Errorf("%w: store is nil", ErrInvalidHighWaterConfig)
+	}
+	// 持久化精确到毫秒，不到1ms的窗口等于每毫秒都要写存储
+	if c.Window < 0 || c.Window > 0 && c.Window < time.Millisecond {
+		return fmt.Errorf("%w: window %v", ErrInvalidHighWaterConfig, c.Window)
+	}
+	return nil
+}
+
+// restore 启动时读取持久化的时间戳，align用来调整读到的unix毫秒
+func (h *highWater) restore(epochMs int64, align func(mark int64) int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), highWaterIOTimeout)
+	defer cancel()
+	mark, err := h.cfg.Store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("load high-water timestamp failed: %w", err)
+	}
+	if mark > 0 && align != nil {
+		mark = align(mark)
+	}
+
+	h.epochMs = epochMs
+	h.start = mark - epochMs
+	if h.start < 0 {
+		h.start = 0
+	}
+	h.reserved.Store(h.start)
+	return nil
+}
+
+// ready 时钟超过启动时的时间戳才可以发号
+func (h *highWater) ready(now func() (int64, error)) error {
+	if h.passed.Load() {
+		return nil
+	}
+	deadline := time.Now().Add(h.cfg.MaxWait)
+	for {
+		ms, err := now()
+		if err != nil {
+			return err
+		}
+		if ms > h.start {
+			h.passed.Store(true)
+			return nil
+		}
+		behind := time.Duration(h.start-ms+1) * time.Millisecond
+		if !h.cfg.Wait || time.Now().Add(behind).After(deadline) {
+			return fmt.Errorf("%w: %s behind", ErrClockBehindHighWater, behind)
+		}
+		time.Sleep(behind)
+	}
+}
+
+// reserve 保证ms已经被持久化；窗口剩余不到一半时在后台持久化下一个窗口，
+// 只有已持久化的窗口用尽时才同步等待写存储
+func (h *highWater) reserve(ms int64) error {
+	reserved := h.reserved.Load()
+	if ms > reserved {
+		return h.persist(ms, ms)
+	}
+	if reserved-ms <= h.cfg.Window.Milliseconds()/2 && h.saving.CompareAndSwap(false, true) {
+		go func() {
+			defer h.saving.Store(false)
+			// 失败时等窗口用尽后同步重试，由发号的调用方拿到错误
+			if err := h.persist(reserved+1, ms); err != nil {
+				log.WarnWithCtxFields(context.Background(), "save high-water timestamp in background failed", zap.Error(err))
+			}
+		}()
+	}
+	return nil
+}
+
+// persist 持久化到ms之后一个窗口，已经持久化到need时直接返回
+func (h *highWater) persist(need, ms int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if need <= h.reserved.Load() {
+		return nil
+	}
+
+	next := ms + h.cfg.Window.Milliseconds()
+	ctx, cancel := context.WithTimeout(context.Background(), highWaterIOTimeout)
+	defer cancel()
+	if err := h.cfg.Store.Save(ctx, h.epochMs+next); err != nil {
+		return fmt.Errorf("save high-water timestamp failed: %w", err)
+	}
+	h.reserved.Store(next)
+	return nil
+}
@@ -0,0 +1,187 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type failingHighWaterStore struct{}
+
+func (failingHighWaterStore) Load(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (failingHighWaterStore) Save(ctx context.Context, ms int64) error {
+	return errors.New("disk full")
+}
+
+func TestGeneratorHighWater(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileHighWaterStore(filepath.Join(t.TempDir(), "snowflake.hw"))
+	clock := newManualClock()
+	cfg := HighWaterConfig{Store: store, Window: 50 * time.Millisecond}
+
+	g, err := NewGenerator(1, WithClock(clock), WithHighWater(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mark, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := clock.Now().Add(50 * time.Millisecond).UnixMilli(); mark != expected {
+		t.Fatalf("persisted %d, expected %d", mark, expected)
+	}
+
+	// 窗口内不再写存储
+	clock.Add(10 * time.Millisecond)
+	if last, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := store.Load(ctx); again != mark {
+		t.Fatalf("persisted %d inside window, expected %d", again, mark)
+	}
+
+	// 重启后时钟回拨，不能发号
+	clock.Add(-time.Second)
+	restarted, err := NewGenerator(1, WithClock(clock), WithHighWater(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = restarted.NextId(); !errors.Is(err, ErrClockBehindHighWater) {
+		t.Fatalf("expected ErrClockBehindHighWater, got %v", err)
+	}
+	if _, err = restarted.NextN(3); !errors.Is(err, ErrClockBehindHighWater) {
+		t.Fatalf("expected ErrClockBehindHighWater, got %v", err)
+	}
+
+	// 时钟追上持久化的时间戳之后恢复
+	clock.Add(time.Second + 50*time.Millisecond)
+	id, err := restarted.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id <= last {
+		t.Fatalf("id %d after restart not greater than %d", id, last)
+	}
+
+	broken, err := NewGenerator(1, WithClock(clock), WithHighWater(HighWaterConfig{Store: failingHighWaterStore{}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = broken.NextId(); err == nil {
+		t.Fatal("expected error when high-water timestamp can't be saved")
+	}
+}
+
+func TestGeneratorHighWaterInvalidConfig(t *testing.T) {
+	for _, cfg := range []HighWaterConfig{
+		{},
+		{Store: failingHighWaterStore{}, Window: -time.Second},
+		{Store: failingHighWaterStore{}, Window: time.Microsecond},
+	} {
+		if _, err := NewGenerator(1, WithHighWater(cfg)); !errors.Is(err, ErrInvalidHighWaterConfig) {
+			t.Fatalf("%+v: expected ErrInvalidHighWaterConfig, got %v", cfg, err)
+		}
+	}
+	if err := EnableDefaultHighWater(HighWaterConfig{}); !errors.Is(err, ErrInvalidHighWaterConfig) {
+		t.Fatalf("expected ErrInvalidHighWaterConfig, got %v", err)
+	}
+}
+
+// gatedHighWaterStore gate关闭时Save阻塞
+type gatedHighWaterStore struct {
+	gate  chan struct{}
+	saved atomic.Int64
+}
+
+func (s *gatedHighWaterStore) Load(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (s *gatedHighWaterStore) Save(ctx context.Context, ms int64) error {
+	<-s.gate
+	s.saved.Store(ms)
+	return nil
+}
+
+func TestGeneratorHighWaterBackgroundSave(t *testing.T) {
+	store := &gatedHighWaterStore{gate: make(chan struct{})}
+	clock := newManualClock()
+	g, err := NewGenerator(1, WithClock(clock), WithHighWater(HighWaterConfig{Store: store, Window: 100 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(store.gate)
+	if _, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 窗口剩余不到一半时在后台写存储，发号不等待
+	store.gate = make(chan struct{})
+	clock.Add(60 * time.Millisecond)
+	if _, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 窗口用尽后等待写入完成
+	clock.Add(50 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		_, err := g.NextId()
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		t.Fatalf("expected NextId to wait for save, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(store.gate)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if saved, now := store.saved.Load(), clock.Now().UnixMilli(); saved < now {
+		t.Fatalf("persisted %d behind issued timestamp %d", saved, now)
+	}
+}
+
+func TestRedisHighWaterStore(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	store := NewRedisHighWaterStore(client, "snowflake:hw:1")
+
+	if ms, err := store.Load(ctx); err != nil || ms != 0 {
+		t.Fatalf("expected 0 before first save, got %d %v", ms, err)
+	}
+	if err := store.Save(ctx, 2000); err != nil {
+		t.Fatal(err)
+	}
+	// 只增不减
+	if err := store.Save(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if ms, err := store.Load(ctx); err != nil || ms != 2000 {
+		t.Fatalf("expected 2000, got %d %v", ms, err)
+	}
+
+	clock := newManualClock()
+	g, err := NewGenerator(1, WithClock(clock), WithHighWater(HighWaterConfig{Store: store, Window: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := mr.Get("snowflake:hw:1"); v != strconv.FormatInt(clock.Now().Add(50*time.Millisecond).UnixMilli(), 10) {
+		t.Fatalf("unexpected persisted value %q", v)
+	}
+}
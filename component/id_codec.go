@@ -0,0 +1,239 @@
+package component
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidEncodedId   = errors.New("invalid encoded id")
+	ErrIdChecksumMismatch = errors.New("encoded id checksum mismatch")
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// IdCodec 把ID可逆地编码成短字符串，适合放在分享链接或者电话里念的订单号中
+type IdCodec struct {
+	alphabet  string
+	values    [256]byte // 字符到数值的映射，非法字符为0xFF
+	crockford bool      // Crockford Base32 大小写不敏感，I L O 视为 1 1 0，忽略连字符
+	checkChar bool
+	tenant    string
+}
+
+type IdCodecOption func(c *IdCodec)
+
+func applyIdCodecOptions(c *IdCodec, opts ...IdCodecOption) {
+	for _, o := range opts {
+		o(c)
+	}
+}
+
+// WithCheckChar 在末尾追加一位校验字符（Luhn mod N），可以发现单个字符输错和相邻字符颠倒
+func WithCheckChar() IdCodecOption {
+	return func(c *IdCodec) {
+		c.checkChar = true
+	}
+}
+
+// WithTenantAlphabet 按租户打乱字母表，不同租户的编码互不相通，连续的ID也不容易被猜出
+func WithTenantAlphabet(tenant string) IdCodecOption {
+	return func(c *IdCodec) {
+		c.tenant = tenant
+	}
+}
+
+// NewBase62Codec 0-9A-Za-z，int64最长11位
+func NewBase62Codec(opts ...IdCodecOption) *IdCodec {
+	return newIdCodec(base62Alphabet, false, opts...)
+}
+
+// NewCrockfordCodec Crockford Base32，int64最长13位，大小写不敏感，适合人工输入
+func NewCrockfordCodec(opts ...IdCodecOption) *IdCodec {
+	return newIdCodec(crockfordAlphabet, true, opts...)
+}
+
+func newIdCodec(alphabet string, crockford bool, opts ...IdCodecOption) *IdCodec {
+	c := &IdCodec{alphabet: alphabet, crockford: crockford}
+	applyIdCodecOptions(c, opts...)
+	if c.tenant != "" {
+		c.alphabet = shuffleAlphabet(alphabet, c.tenant)
+	}
+
+	for i := range c.values {
+		c.values[i] = 0xFF
+	}
+	for i := 0; i < len(c.alphabet); i++ {
+		ch := c.alphabet[i]
+		c.values[ch] = byte(i)
+		if crockford && ch >= 'A' && ch <= 'Z' {
+			c.values[ch+'a'-'A'] = byte(i)
+		}
+	}
+	if crockford {
+		for _, alias := range []struct{ from, to byte }{{'I', '1'}, {'i', '1'}, {'L', '1'}, {'l', '1'}, {'O', '0'}, {'o', '0'}} {
+			c.values[alias.from] = c.values[alias.to]
+		}
+	}
+	return c
+}
+
+// Encode 编码非负的int64 ID，比如 Generator 生成的ID
+func (c *IdCodec) Encode(id int64) (string, error) {
+	if id < 0 {
+		return "", fmt.Errorf("%w: negative id %d", ErrInvalidEncodedId, id)
+	}
+	base := uint64(len(c.alphabet))
+	var buf [65]byte
+	i := len(buf)
+	for v := uint64(id); ; v /= base {
+		i--
+		buf[i] = c.alphabet[v%base]
+		if v < base {
+			break
+		}
+	}
+	return c.withCheck(string(buf[i:])), nil
+}
+
+// Decode 还原 Encode 编码的ID
+func (c *IdCodec) Decode(s string) (int64, error) {
+	digits, err := c.digits(s)
+	if err != nil {
+		return 0, err
+	}
+	base := uint64(len(c.alphabet))
+	var v uint64
+	for _, d := range digits {
+		if v > (math.MaxInt64-uint64(d))/base {
+			return 0, fmt.Errorf("%w: %q overflows int64", ErrInvalidEncodedId, s)
+		}
+		v = v*base + uint64(d)
+	}
+	return int64(v), nil
+}
+
+// EncodeDecimal 编码任意长度的十进制字符串ID，比如 CreateSnowflakeId 在machineId为数字时生成的ID
+func (c *IdCodec) EncodeDecimal(id string) (string, error) {
+	if id == "" || (len(id) > 1 && id[0] == '0') {
+		return "", fmt.Errorf("%w: %q is not a canonical decimal", ErrInvalidEncodedId, id)
+	}
+	v, ok := new(big.Int).SetString(id, 10)
+	if !ok || v.Sign() < 0 {
+		return "", fmt.Errorf("%w: %q is not a canonical decimal", ErrInvalidEncodedId, id)
+	}
+
+	base := big.NewInt(int64(len(c.alphabet)))
+	mod := new(big.Int)
+	var out []byte
+	for v.Sign() > 0 {
+		v.DivMod(v, base, mod)
+		out = append(out, c.alphabet[mod.Int64()])
+	}
+	if len(out) == 0 {
+		out = append(out, c.alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return c.withCheck(string(out)), nil
+}
+
+// DecodeDecimal 还原 EncodeDecimal 编码的十进制字符串
+func (c *IdCodec) DecodeDecimal(s string) (string, error) {
+	digits, err := c.digits(s)
+	if err != nil {
+		return "", err
+	}
+	base := big.NewInt(int64(len(c.alphabet)))
+	v := new(big.Int)
+	for _, d := range digits {
+		v.Mul(v, base).Add(v, big.NewInt(int64(d)))
+	}
+	return v.String(), nil
+}
+
+// digits 去掉分隔符、校验并去掉校验字符，返回每一位的数值
+func (c *IdCodec) digits(s string) ([]byte, error) {
+	if c.crockford {
+		s = strings.ReplaceAll(s, "-", "")
+	}
+	minLen := 1
+	if c.checkChar {
+		minLen = 2
+	}
+	if len(s) < minLen {
+		return nil, fmt.Errorf("%w: %q is too short", ErrInvalidEncodedId, s)
+	}
+
+	digits := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		d := c.values[s[i]]
+		if d == 0xFF {
+			return nil, fmt.Errorf("%w: illegal character %q", ErrInvalidEncodedId, s[i])
+		}
+		digits[i] = d
+	}
+	if c.checkChar {
+		last := len(digits) - 1
+		if c.checkDigit(digits[:last]) != digits[last] {
+			return nil, fmt.Errorf("%w: %q", ErrIdChecksumMismatch, s)
+		}
+		digits = digits[:last]
+	}
+	return digits, nil
+}
+
+func (c *IdCodec) withCheck(encoded string) string {
+	if !c.checkChar {
+		return encoded
+	}
+	digits := make([]byte, len(encoded))
+	for i := 0; i < len(encoded); i++ {
+		digits[i] = c.values[encoded[i]]
+	}
+	return encoded + string(c.alphabet[c.checkDigit(digits)])
+}
+
+// checkDigit Luhn mod N 校验位
+func (c *IdCodec) checkDigit(digits []byte) byte {
+	n := len(c.alphabet)
+	factor, sum := 2, 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		addend := factor * int(digits[i])
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+	return byte((n - sum%n) % n)
+}
+
+// shuffleAlphabet 以租户为种子确定性地打乱字母表，使用sha256计数器作为随机流，不依赖math/rand的实现
+func shuffleAlphabet(alphabet, tenant string) string {
+	out := []byte(alphabet)
+	var counter uint64
+	var block [sha256.Size]byte
+	offset := len(block)
+	next := func() uint64 {
+		if offset+8 > len(block) {
+			h := sha256.New()
+			h.Write([]byte(tenant))
+			_ = binary.Write(h, binary.BigEndian, counter)
+			copy(block[:], h.Sum(nil))
+			counter++
+			offset = 0
+		}
+		v := binary.BigEndian.Uint64(block[offset:])
+		offset += 8
+		return v
+	}
+	for i := len(out) - 1; i > 0; i-- {
+		j := next() % uint64(i+1)
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,120 @@
+package component
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIdCodecRoundTrip(t *testing.T) {
+	codecs := map[string]*IdCodec{
+		"base62":          NewBase62Codec(),
+		"base62-check":    NewBase62Codec(WithCheckChar()),
+		"crockford":       NewCrockfordCodec(),
+		"crockford-check": NewCrockfordCodec(WithCheckChar(), WithTenantAlphabet("tenant-a")),
+	}
+	g, err := NewGenerator(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range codecs {
+		for _, id := range []int64{0, 1, 61, 62, 12345678, generated, math.MaxInt64} {
+			s, err := c.Encode(id)
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			got, err := c.Decode(s)
+			if err != nil {
+				t.Fatal(name, s, err)
+			}
+			if got != id {
+				t.Fatalf("%s: decode %q got %d, expected %d", name, s, got, id)
+			}
+		}
+
+		long := CreateSnowflakeId("0118")
+		s, err := c.EncodeDecimal(long)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if len(s) >= len(long) {
+			t.Fatalf("%s: %q is not shorter than %q", name, s, long)
+		}
+		got, err := c.DecodeDecimal(s)
+		if err != nil || got != long {
+			t.Fatalf("%s: decode %q got %q, %v", name, s, got, err)
+		}
+	}
+
+	if _, err = codecs["base62"].Encode(-1); !errors.Is(err, ErrInvalidEncodedId) {
+		t.Fatalf("expected ErrInvalidEncodedId, got %v", err)
+	}
+	if _, err = codecs["base62"].Decode("zzzzzzzzzzzz"); !errors.Is(err, ErrInvalidEncodedId) {
+		t.Fatalf("expected overflow error, got %v", err)
+	}
+}
+
+func TestIdCodecCheckChar(t *testing.T) {
+	c := NewCrockfordCodec(WithCheckChar())
+	s, err := c.Encode(1234567890123)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 人工输入时大小写、易混字符和连字符都可以接受
+	typed := strings.ToLower(s[:4]) + "-" + s[4:]
+	typed = strings.NewReplacer("0", "o", "1", "l").Replace(typed)
+	if id, err := c.Decode(typed); err != nil || id != 1234567890123 {
+		t.Fatalf("decode %q got %d, %v", typed, id, err)
+	}
+
+	// 任意一位输错都能发现
+	for i := 0; i < len(s); i++ {
+		for _, r := range crockfordAlphabet {
+			if byte(r) == s[i] {
+				continue
+			}
+			wrong := s[:i] + string(r) + s[i+1:]
+			if _, err := c.Decode(wrong); !errors.Is(err, ErrIdChecksumMismatch) {
+				t.Fatalf("typo %q of %q not detected: %v", wrong, s, err)
+			}
+		}
+	}
+
+	// 相邻两位颠倒
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] == s[i+1] {
+			continue
+		}
+		swapped := s[:i] + string(s[i+1]) + string(s[i]) + s[i+2:]
+		if _, err := c.Decode(swapped); err == nil {
+			t.Fatalf("transposition %q of %q not detected", swapped, s)
+		}
+	}
+}
+
+func TestIdCodecTenantAlphabet(t *testing.T) {
+	a := NewBase62Codec(WithTenantAlphabet("tenant-a"))
+	b := NewBase62Codec(WithTenantAlphabet("tenant-b"))
+	plain := NewBase62Codec()
+
+	sa, _ := a.Encode(987654321)
+	sb, _ := b.Encode(987654321)
+	sp, _ := plain.Encode(987654321)
+	if sa == sb || sa == sp {
+		t.Fatalf("tenant alphabets should differ: %q %q %q", sa, sb, sp)
+	}
+	// 同一租户的编码是确定的
+	if again, _ := NewBase62Codec(WithTenantAlphabet("tenant-a")).Encode(987654321); again != sa {
+		t.Fatalf("tenant alphabet not deterministic: %q %q", again, sa)
+	}
+	if id, err := a.Decode(sa); err != nil || id != 987654321 {
+		t.Fatalf("decode %q got %d, %v", sa, id, err)
+	}
+}
@@ -0,0 +1,371 @@
+package component
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jordan-wright/email"
+)
+
+var ErrMailerClosed = errors.New("mailer closed")
+
+// Mailer 邮件的发送方式，EmailVerifier 默认使用SMTP，测试时可以换成 MemoryMailer 或 FileMailer
+type Mailer interface {
+	Send(ctx context.Context, msg *email.Email) error
+}
+
+const (
+	defaultSMTPPoolSize    = 4
+	defaultSMTPIdleTimeout = 30 * time.Second
+	defaultSMTPDialTimeout = 10 * time.Second
+)
+
+// SMTPMailer 通过连接池复用SMTP连接，默认在服务器支持时STARTTLS，可以通过 WithSMTPTLS 修改；
+// 应当在进程内共享同一个实例
+type SMTPMailer struct {
+	addr        string
+	host        string
+	auth        smtp.Auth
+	poolSize    int
+	idleTimeout time.Duration
+	dialTimeout time.Duration
+	tlsMode     SMTPTLSMode
+	tlsConfig   *tls.Config
+	dkim        *DKIMSigner
+
+	sem    chan struct{} // 限制同时打开的连接数
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+type smtpConn struct {
+	*smtp.Client
+	conn     net.Conn
+	lastUsed time.Time
+}
+
+type SMTPOption func(m *SMTPMailer)
+
+// WithSMTPPoolSize 最多同时打开的连接数，默认4
+func WithSMTPPoolSize(size int) SMTPOption {
+	return func(m *SMTPMailer) {
+		m.poolSize = size
+	}
+}
+
+// WithSMTPIdleTimeout 空闲超过该时间的连接不再复用，应当小于服务器的空闲超时，默认30s
+func WithSMTPIdleTimeout(timeout time.Duration) SMTPOption {
+	return func(m *SMTPMailer) {
+		m.idleTimeout = timeout
+	}
+}
+
+// WithSMTPDialTimeout 建立连接的超时时间，默认10s
+func WithSMTPDialTimeout(timeout time.Duration) SMTPOption {
+	return func(m *SMTPMailer) {
+		m.dialTimeout = timeout
+	}
+}
+
+// NewSMTPMailer addr为 host:port，auth为空时不认证
+func NewSMTPMailer(addr string, auth smtp.Auth, opts ...SMTPOption) *SMTPMailer {
+	host, _, _ := net.SplitHostPort(addr)
+	m := &SMTPMailer{
+		addr:        addr,
+		host:        host,
+		auth:        auth,
+		poolSize:    defaultSMTPPoolSize,
+		idleTimeout: defaultSMTPIdleTimeout,
+		dialTimeout: defaultSMTPDialTimeout,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	if m.poolSize <= 0 {
+		m.poolSize = 1
+	}
+	m.sem = make(chan struct{}, m.poolSize)
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *email.Email) error {
+	from, recipients, err := envelope(msg)
+	if err != nil {
+		return err
+	}
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if m.dkim != nil {
+		if raw, err = m.dkim.Sign(raw); err != nil {
+			return err
+		}
+	}
+	return m.sendRaw(ctx, from, recipients, raw)
+}
+
+// sendRaw 复用的连接可能已经被服务器关闭，这种情况下重新建立连接再试一次
+func (m *SMTPMailer) sendRaw(ctx context.Context, from string, recipients []string, raw []byte) error {
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-m.sem }()
+
+	c, reused, err := m.get(ctx)
+	if err != nil {
+		return err
+	}
+	err = deliver(ctx, c, from, recipients, raw)
+	if err != nil && reused && !isSMTPReply(err) {
+		c.Close()
+		if c, err = m.dial(ctx); err != nil {
+			return err
+		}
+		err = deliver(ctx, c, from, recipients, raw)
+	}
+	m.release(c, err)
+	return err
+}
+
+func (m *SMTPMailer) get(ctx context.Context) (*smtpConn, bool, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, false, ErrMailerClosed
+	}
+	for len(m.idle) > 0 {
+		c := m.idle[len(m.idle)-1]
+		m.idle = m.idle[:len(m.idle)-1]
+		if time.Since(c.lastUsed) > m.idleTimeout {
+			c.Close()
+			continue
+		}
+		m.mu.Unlock()
+		return c, true, nil
+	}
+	m.mu.Unlock()
+
+	c, err := m.dial(ctx)
+	return c, false, err
+}
+
+// release 发送成功或者服务器正常拒绝时连接还可以继续使用
+func (m *SMTPMailer) release(c *smtpConn, err error) {
+	if err != nil && (!isSMTPReply(err) || c.Reset() != nil) {
+		c.Close()
+		return
+	}
+	c.lastUsed = time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		c.Close()
+		return
+	}
+	m.idle = append(m.idle, c)
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (*smtpConn, error) {
+	dialer := net.Dialer{Timeout: m.dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(m.dialTimeout))
+	}
+	if m.tlsMode == SMTPTLSImplicit {
+		// smtp.Client 通过连接类型判断是否已经加密
+		conn = tls.Client(conn, m.clientTLSConfig())
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c := &smtpConn{Client: client, conn: conn}
+	if m.tlsMode == SMTPTLSOpportunistic || m.tlsMode == SMTPTLSRequired {
+		ok, _ := client.Extension("STARTTLS")
+		if ok {
+			err = client.StartTLS(m.clientTLSConfig())
+		} else if m.tlsMode == SMTPTLSRequired {
+			err = ErrSTARTTLSUnsupported
+		}
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if m.auth != nil {
+		if err = client.Auth(m.auth); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (m *SMTPMailer) clientTLSConfig() *tls.Config {
+	if m.tlsConfig == nil {
+		return &tls.Config{ServerName: m.host}
+	}
+	config := m.tlsConfig.Clone()
+	if config.ServerName == "" {
+		config.ServerName = m.host
+	}
+	return config
+}
+
+// Close 关闭空闲连接，之后不能再发送
+func (m *SMTPMailer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for _, c := range m.idle {
+		c.conn.SetDeadline(time.Now().Add(time.Second))
+		_ = c.Quit()
+		c.Close()
+	}
+	m.idle = nil
+	return nil
+}
+
+func deliver(ctx context.Context, c *smtpConn, from string, recipients []string, raw []byte) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+		defer c.conn.SetDeadline(time.Time{})
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, r := range recipients {
+		if err := c.Rcpt(r); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(raw); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// isSMTPReply 服务器返回的错误码，连接本身没有问题
+func isSMTPReply(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply)
+}
+
+// envelope 信封中的发件人和所有收件人地址
+func envelope(msg *email.Email) (string, []string, error) {
+	sender := msg.Sender
+	if sender == "" {
+		sender = msg.From
+	}
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return "", nil, err
+	}
+	recipients := make([]string, 0, len(msg.To)+len(msg.Cc)+len(msg.Bcc))
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, r := range list {
+			addr, err := mail.ParseAddress(r)
+			if err != nil {
+				return "", nil, err
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return "", nil, errors.New("no recipients")
+	}
+	return from.Address, recipients, nil
+}
+
+// FileMailer 把邮件写成.eml文件，可以直接用邮件客户端打开检查排版
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+// Send 文件名为ULID，按发送顺序排列
+func (m *FileMailer) Send(ctx context.Context, msg *email.Email) error {
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	id, err := NewULID()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.dir, id.String()+".eml"), raw, 0o644)
+}
+
+// MemoryMailer 把邮件记录在内存中，用于测试断言
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []*email.Email
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg *email.Email) error {
+	cp := copyEmail(msg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, cp)
+	return nil
+}
+
+// Messages 按发送顺序返回所有邮件
+func (m *MemoryMailer) Messages() []*email.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*email.Email(nil), m.messages...)
+}
+
+// Last 最后一封邮件，没有时返回nil
+func (m *MemoryMailer) Last() *email.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return nil
+	}
+	return m.messages[len(m.messages)-1]
+}
+
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
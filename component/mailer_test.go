@@ -0,0 +1,317 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jordan-wright/email"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "", WithMailer(mailer))
+	if err := e.SendContentEmail("first", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SendContentEmail("second", []byte("world")); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	// 复用同一个EmailVerifier不会影响已经记录的邮件
+	if messages[0].Subject != "first" || string(messages[0].Text) != "hello" {
+		t.Fatalf("unexpected first message %+v", messages[0])
+	}
+	if last := mailer.Last(); last.Subject != "second" || last.To[0] != "to@example.com" {
+		t.Fatalf("unexpected last message %+v", last)
+	}
+	mailer.Reset()
+	if mailer.Last() != nil {
+		t.Fatal("expected no messages after reset")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFileMailer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "", WithMailer(mailer))
+	for i := 0; i < 2; i++ {
+		if err = e.SendContentEmail("subject", []byte("body")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 eml files, got %v", files)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte("Subject: subject")) || !bytes.Contains(raw, []byte("To: <to@example.com>")) {
+		t.Fatalf("unexpected eml content:\n%s", raw)
+	}
+}
+
+// fakeSMTPServer 只实现发送邮件需要的几个命令，记录连接数、认证信息和收到的邮件
+type fakeSMTPServer struct {
+	lis         net.Listener
+	extensions  []string // EHLO返回的扩展，比如 "AUTH LOGIN CRAM-MD5"
+	connections atomic.Int32
+	mu          sync.Mutex
+	messages    []string
+	auths       []string
+}
+
+const fakeSMTPPassword = "secret"
+
+func newFakeSMTPServer(t *testing.T, extensions ...string) *fakeSMTPServer {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return startFakeSMTPServer(t, lis, extensions...)
+}
+
+func startFakeSMTPServer(t *testing.T, lis net.Listener, extensions ...string) *fakeSMTPServer {
+	s := &fakeSMTPServer{lis: lis, extensions: extensions}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			s.connections.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { lis.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			for _, ext := range s.extensions {
+				text.PrintfLine("250-%s", ext)
+			}
+			text.PrintfLine("250 fake")
+		case "AUTH":
+			if !s.auth(text, strings.Fields(line)[1:]) {
+				text.PrintfLine("535 authentication failed")
+				continue
+			}
+			text.PrintfLine("235 authenticated")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default: // MAIL RCPT RSET NOOP
+			text.PrintfLine("250 ok")
+		}
+	}
+}
+
+// auth 记录 "机制 用户名 密码"，CRAM-MD5只校验密码是否为fakeSMTPPassword
+func (s *fakeSMTPServer) auth(text *textproto.Conn, args []string) bool {
+	challenge := func(prompt string) string {
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, _ := text.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+	var record string
+	switch strings.ToUpper(args[0]) {
+	case "PLAIN":
+		var resp []byte
+		if len(args) > 1 {
+			resp, _ = base64.StdEncoding.DecodeString(args[1])
+		} else {
+			resp = []byte(challenge(""))
+		}
+		parts := strings.Split(string(resp), "\x00")
+		if len(parts) != 3 {
+			return false
+		}
+		record = "PLAIN " + parts[1] + " " + parts[2]
+	case "LOGIN":
+		user := challenge("Username:")
+		record = "LOGIN " + user + " " + challenge("Password:")
+	case "CRAM-MD5":
+		const nonce = "<1896.697170952@fake>"
+		user, digest, _ := strings.Cut(challenge(nonce), " ")
+		mac := hmac.New(md5.New, []byte(fakeSMTPPassword))
+		mac.Write([]byte(nonce))
+		if digest != hex.EncodeToString(mac.Sum(nil)) {
+			return false
+		}
+		record = "CRAM-MD5 " + user
+	default:
+		return false
+	}
+	s.mu.Lock()
+	s.auths = append(s.auths, record)
+	s.mu.Unlock()
+	return true
+}
+
+func (s *fakeSMTPServer) authenticated() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auths...)
+}
+
+func (s *fakeSMTPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func TestSMTPMailerPool(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.lis.Addr().String(), nil, WithSMTPPoolSize(2))
+	defer mailer.Close()
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "", "", "", "", WithMailer(mailer))
+
+	for i := 0; i < 5; i++ {
+		if err := e.SendContentEmail("subject", []byte("body")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := server.connections.Load(); n != 1 {
+		t.Fatalf("expected connection to be reused, got %d connections", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := &email.Email{From: "from@example.com", To: []string{"to@example.com"}, Text: []byte("hi")}
+			if err := mailer.Send(context.Background(), msg); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := server.connections.Load(); n > 2 {
+		t.Fatalf("pool size exceeded: %d connections", n)
+	}
+	if n := len(server.received()); n != 15 {
+		t.Fatalf("expected 15 messages, got %d", n)
+	}
+}
+
+func TestSMTPAuthMechanisms(t *testing.T) {
+	server := newFakeSMTPServer(t, "AUTH PLAIN LOGIN CRAM-MD5")
+	addr := server.lis.Addr().String()
+	for _, mechanism := range []string{SMTPAuthLogin, SMTPAuthCRAMMD5} {
+		e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "user", fakeSMTPPassword,
+			"127.0.0.1", addr, WithSMTPAuthMechanism(mechanism))
+		if err := e.SendContentEmail("subject", []byte("body")); err != nil {
+			t.Fatalf("%s: %v", mechanism, err)
+		}
+	}
+	got := server.authenticated()
+	if len(got) != 2 || got[0] != "LOGIN user secret" || got[1] != "CRAM-MD5 user" {
+		t.Fatalf("unexpected auths %v", got)
+	}
+
+	if _, err := NewSMTPAuth("XOAUTH2", "user", "pass", "127.0.0.1"); err == nil {
+		t.Fatal("expected unsupported mechanism error")
+	}
+	// 非本机的明文连接不能发送LOGIN密码
+	login, _ := NewSMTPAuth(SMTPAuthLogin, "user", "pass", "mail.example.com")
+	if _, _, err := login.Start(&smtp.ServerInfo{Name: "mail.example.com"}); err == nil {
+		t.Fatal("expected LOGIN to be refused on unencrypted connection")
+	}
+
+	// 同一个实例被多个连接交错使用时，仍然按提示语回答
+	for _, tt := range []struct{ prompt, expected string }{
+		{"Password:", "pass"}, {"Username:", "user"}, {"User Name", "user"}, {"Password:", "pass"},
+	} {
+		if got, err := login.Next([]byte(tt.prompt), true); err != nil || string(got) != tt.expected {
+			t.Fatalf("%q: expected %q, got %q %v", tt.prompt, tt.expected, got, err)
+		}
+	}
+	if _, err := login.Next([]byte("Token:"), true); err == nil {
+		t.Fatal("expected error for unknown challenge")
+	}
+}
+
+func TestSMTPTLSModes(t *testing.T) {
+	// STARTTLS是必须的但服务器不支持
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.lis.Addr().String(), nil, WithSMTPTLS(SMTPTLSRequired, nil))
+	defer mailer.Close()
+	msg := &email.Email{From: "from@example.com", To: []string{"to@example.com"}, Text: []byte("hi")}
+	if err := mailer.Send(context.Background(), msg); !errors.Is(err, ErrSTARTTLSUnsupported) {
+		t.Fatalf("expected ErrSTARTTLSUnsupported, got %v", err)
+	}
+
+	// 465端口的直接TLS，加密连接上可以使用PLAIN认证
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	defer https.Close()
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsServer := startFakeSMTPServer(t, tls.NewListener(raw, https.TLS), "AUTH PLAIN")
+	clientConfig := https.Client().Transport.(*http.Transport).TLSClientConfig
+	e := GetNewEmail([]string{"to@example.com"}, nil, "from@example.com", "user", fakeSMTPPassword,
+		"127.0.0.1", raw.Addr().String(), WithSMTPOptions(WithSMTPTLS(SMTPTLSImplicit, clientConfig)))
+	if err = e.SendContentEmail("subject", []byte("body")); err != nil {
+		t.Fatal(err)
+	}
+	if got := tlsServer.authenticated(); len(got) != 1 || got[0] != "PLAIN user secret" {
+		t.Fatalf("unexpected auths %v", got)
+	}
+	if len(tlsServer.received()) != 1 {
+		t.Fatal("expected message over implicit tls")
+	}
+}
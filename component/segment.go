@@ -0,0 +1,261 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdGenerator 雪花算法 Generator 与号段 SegmentGenerator 的公共接口，业务可以在两者之间切换
+type IdGenerator interface {
+	NextId() (int64, error)
+	NextN(n int) ([]int64, error)
+}
+
+var (
+	_ IdGenerator = (*Generator)(nil)
+	_ IdGenerator = (*SegmentGenerator)(nil)
+)
+
+var ErrSegmentExhausted = errors.New("id segment exhausted and next segment not ready")
+
+const (
+	defaultSegmentStep          = 1000
+	defaultSegmentPrefetchRatio = 0.2
+	defaultSegmentTimeout       = 3 * time.Second
+	defaultSegmentKeyPrefix     = "id:segment:"
+)
+
+// SegmentStore 号段的存储，每次为业务标签预留一段号码
+type SegmentStore interface {
+	// Next 为tag预留step个号码，返回号段的最大值（包含）
+	Next(ctx context.Context, tag string, step int64) (int64, error)
+}
+
+// RedisSegmentStore 每个业务标签一个计数键，通过INCRBY预留号段
+type RedisSegmentStore struct {
+	client    redis.Cmdable
+	keyPrefix string
+}
+
+type RedisSegmentStoreOption func(s *RedisSegmentStore)
+
+// WithSegmentKeyPrefix 计数键前缀，默认 id:segment:
+func WithSegmentKeyPrefix(prefix string) RedisSegmentStoreOption {
+	return func(s *RedisSegmentStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+func NewRedisSegmentStore(client redis.Cmdable, opts ...RedisSegmentStoreOption) *RedisSegmentStore {
+	s := &RedisSegmentStore{client: client, keyPrefix: defaultSegmentKeyPrefix}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *RedisSegmentStore) Next(ctx context.Context, tag string, step int64) (int64, error) {
+	return s.client.IncrBy(ctx, s.keyPrefix+tag, step).Result()
+}
+
+// MemorySegmentStore 计数保存在map中，重启后从0开始，只适合不需要持久化的号码
+type MemorySegmentStore struct {
+	mu   sync.Mutex
+	maxs map[string]int64
+}
+
+func NewMemorySegmentStore() *MemorySegmentStore {
+	return &MemorySegmentStore{maxs: map[string]int64{}}
+}
+
+func (s *MemorySegmentStore) Next(ctx context.Context, tag string, step int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxs[tag] += step
+	return s.maxs[tag], nil
+}
+
+// 号段 [cur, max]
+type segment struct {
+	cur int64
+	max int64
+}
+
+func (s *segment) remain() int64 {
+	return s.max - s.cur + 1
+}
+
+// SegmentGenerator 不依赖时钟的号段分配器，双缓冲：当前号段用掉一定比例后异步预取下一段
+type SegmentGenerator struct {
+	store         SegmentStore
+	tag           string
+	step          int64
+	prefetchRatio float64
+	timeout       time.Duration
+
+	mu      sync.Mutex
+	cur     *segment
+	next    *segment
+	loading chan struct{} // 正在预取时不为空，预取结束后关闭
+	loadErr error         // 最近一次预取的错误
+}
+
+type SegmentOption func(g *SegmentGenerator)
+
+func applySegmentOptions(g *SegmentGenerator, opts ...SegmentOption) {
+	for _, o := range opts {
+		o(g)
+	}
+}
+
+// WithSegmentStep 每个号段的长度，默认1000
+func WithSegmentStep(step int64) SegmentOption {
+	return func(g *SegmentGenerator) {
+		g.step = step
+	}
+}
+
+// WithPrefetchRatio 当前号段用掉多少比例后开始预取下一段，默认0.2
+func WithPrefetchRatio(ratio float64) SegmentOption {
+	return func(g *SegmentGenerator) {
+		g.prefetchRatio = ratio
+	}
+}
+
+// WithSegmentTimeout 访问号段存储的超时时间，默认3s
+func WithSegmentTimeout(timeout time.Duration) SegmentOption {
+	return func(g *SegmentGenerator) {
+		g.timeout = timeout
+	}
+}
+
+// NewSegmentGenerator 创建时同步加载第一个号段，存储不可用时直接返回错误
+func NewSegmentGenerator(ctx context.Context, store SegmentStore, tag string,
+	opts ...SegmentOption) (*SegmentGenerator, error) {
+	g := &SegmentGenerator{
+		store:         store,
+		tag:           tag,
+		step:          defaultSegmentStep,
+		prefetchRatio: defaultSegmentPrefetchRatio,
+		timeout:       defaultSegmentTimeout,
+	}
+	applySegmentOptions(g, opts...)
+	if g.step <= 0 {
+		return nil, fmt.Errorf("invalid segment step %d", g.step)
+	}
+	if g.prefetchRatio < 0 || g.prefetchRatio > 1 {
+		return nil, fmt.Errorf("invalid segment prefetch ratio %f", g.prefetchRatio)
+	}
+
+	seg, err := g.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.cur = seg
+	return g, nil
+}
+
+// NextId 从当前号段取下一个ID
+func (g *SegmentGenerator) NextId() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.ensure(); err != nil {
+		return 0, err
+	}
+	id := g.cur.cur
+	g.cur.cur++
+	g.prefetch()
+	return id, nil
+}
+
+// NextN 一次性取n个单调递增的ID，可能跨越多个号段
+func (g *SegmentGenerator) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid id count %d", n)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make([]int64, 0, n)
+	for len(ids) < n {
+		if err := g.ensure(); err != nil {
+			return nil, err
+		}
+		for ; g.cur.cur <= g.cur.max && len(ids) < n; g.cur.cur++ {
+			ids = append(ids, g.cur.cur)
+		}
+		g.prefetch()
+	}
+	return ids, nil
+}
+
+// Tag 业务标签
+func (g *SegmentGenerator) Tag() string {
+	return g.tag
+}
+
+// ensure 保证当前号段还有可用的号码，调用方需要持有锁
+func (g *SegmentGenerator) ensure() error {
+	for g.cur.remain() <= 0 {
+		if g.next != nil {
+			g.cur, g.next = g.next, nil
+			continue
+		}
+		if g.loading == nil {
+			// 没有触发预取（比如比例设置为1），同步加载
+			g.prefetchLocked()
+		}
+		// 等待预取结束，期间释放锁
+		loading := g.loading
+		g.mu.Unlock()
+		<-loading
+		g.mu.Lock()
+		if g.next == nil && g.loadErr != nil && g.cur.remain() <= 0 {
+			return fmt.Errorf("%w: %s", ErrSegmentExhausted, g.loadErr.Error())
+		}
+	}
+	return nil
+}
+
+// prefetch 当前号段用掉的比例超过阈值时异步加载下一段，调用方需要持有锁
+func (g *SegmentGenerator) prefetch() {
+	if g.next != nil || g.loading != nil {
+		return
+	}
+	used := g.step - g.cur.remain()
+	if float64(used) < float64(g.step)*g.prefetchRatio {
+		return
+	}
+	g.prefetchLocked()
+}
+
+func (g *SegmentGenerator) prefetchLocked() {
+	loading := make(chan struct{})
+	g.loading = loading
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+		seg, err := g.load(ctx)
+		cancel()
+
+		g.mu.Lock()
+		g.next, g.loadErr = seg, err
+		g.loading = nil
+		g.mu.Unlock()
+		close(loading)
+	}()
+}
+
+func (g *SegmentGenerator) load(ctx context.Context) (*segment, error) {
+	end, err := g.store.Next(ctx, g.tag, g.step)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{cur: end - g.step + 1, max: end}, nil
+}
@@ -0,0 +1,134 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// 可以控制失败的号段存储
+type flakySegmentStore struct {
+	*MemorySegmentStore
+	calls atomic.Int32
+	fail  atomic.Bool
+}
+
+func (s *flakySegmentStore) Next(ctx context.Context, tag string, step int64) (int64, error) {
+	s.calls.Add(1)
+	if s.fail.Load() {
+		return 0, errors.New("store unavailable")
+	}
+	return s.MemorySegmentStore.Next(ctx, tag, step)
+}
+
+func TestSegmentGenerator(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySegmentStore()
+
+	// 两个实例共享同一个存储，号码不能重复
+	var gens []IdGenerator
+	for i := 0; i < 2; i++ {
+		g, err := NewSegmentGenerator(ctx, store, "order", WithSegmentStep(50))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gens = append(gens, g)
+	}
+
+	seen := sync.Map{}
+	group := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func(g IdGenerator) {
+			defer group.Done()
+			var last int64
+			for j := 0; j < 500; j++ {
+				id, err := g.NextId()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if id <= last {
+					t.Errorf("id %d not greater than previous %d", id, last)
+					return
+				}
+				last = id
+				if _, loaded := seen.LoadOrStore(id, struct{}{}); loaded {
+					t.Errorf("repeat id %d", id)
+					return
+				}
+			}
+		}(gens[i%2])
+	}
+	group.Wait()
+
+	ids, err := gens[0].NextN(120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("id %d not greater than previous %d", ids[i], ids[i-1])
+		}
+	}
+}
+
+func TestSegmentGeneratorPrefetch(t *testing.T) {
+	ctx := context.Background()
+	store := &flakySegmentStore{MemorySegmentStore: NewMemorySegmentStore()}
+	g, err := NewSegmentGenerator(ctx, store, "user", WithSegmentStep(10), WithPrefetchRatio(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 用掉一半之后触发预取，存储失败时当前号段仍然可以继续使用
+	store.fail.Store(true)
+	for i := 0; i < 10; i++ {
+		if _, err = g.NextId(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = g.NextId(); !errors.Is(err, ErrSegmentExhausted) {
+		t.Fatalf("expected ErrSegmentExhausted, got %v", err)
+	}
+
+	// 存储恢复后重新加载
+	store.fail.Store(false)
+	id, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 11 {
+		t.Fatalf("expected id 11, got %d", id)
+	}
+	if store.calls.Load() < 3 {
+		t.Fatalf("expected prefetch calls, got %d", store.calls.Load())
+	}
+}
+
+func TestRedisSegmentStore(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	store := NewRedisSegmentStore(client, WithSegmentKeyPrefix("seg:"))
+
+	if max, err := store.Next(ctx, "order", 50); err != nil || max != 50 {
+		t.Fatalf("expected 50, got %d %v", max, err)
+	}
+	if max, err := store.Next(ctx, "order", 50); err != nil || max != 100 {
+		t.Fatalf("expected 100, got %d %v", max, err)
+	}
+	if v, err := mr.Get("seg:order"); err != nil || v != "100" {
+		t.Fatalf("unexpected counter %q %v", v, err)
+	}
+
+	// 计数在Redis中，新的生成器从已经预留的号段之后开始
+	g, err := NewSegmentGenerator(ctx, store, "order", WithSegmentStep(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := g.NextId(); err != nil || id != 101 {
+		t.Fatalf("expected 101, got %d %v", id, err)
+	}
+}
@@ -0,0 +1,96 @@
+package component
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+var ErrSTARTTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+
+// SMTPTLSMode 与服务器之间的加密方式
+type SMTPTLSMode int
+
+const (
+	SMTPTLSOpportunistic SMTPTLSMode = iota // 服务器支持时STARTTLS，默认
+	SMTPTLSRequired                         // 必须STARTTLS，服务器不支持时返回ErrSTARTTLSUnsupported
+	SMTPTLSImplicit                         // 连接建立后直接TLS握手，一般是465端口
+	SMTPTLSNone                             // 不加密，只用于本地调试
+)
+
+// WithSMTPTLS 设置加密方式，config为空时只校验服务器证书的域名
+func WithSMTPTLS(mode SMTPTLSMode, config *tls.Config) SMTPOption {
+	return func(m *SMTPMailer) {
+		m.tlsMode = mode
+		m.tlsConfig = config
+	}
+}
+
+// WithDKIM 发送前对邮件做DKIM签名
+func WithDKIM(signer *DKIMSigner) SMTPOption {
+	return func(m *SMTPMailer) {
+		m.dkim = signer
+	}
+}
+
+// SMTP认证方式
+const (
+	SMTPAuthPlain   = "PLAIN"
+	SMTPAuthLogin   = "LOGIN"
+	SMTPAuthCRAMMD5 = "CRAM-MD5"
+)
+
+// NewSMTPAuth 按认证方式创建smtp.Auth，mechanism为空时使用PLAIN；
+// PLAIN和LOGIN只会在加密连接或者连接本机时发送密码
+func NewSMTPAuth(mechanism, username, password, host string) (smtp.Auth, error) {
+	switch strings.ToUpper(mechanism) {
+	case "", SMTPAuthPlain:
+		return smtp.PlainAuth("", username, password, host), nil
+	case SMTPAuthLogin:
+		return &loginAuth{username: username, password: password, host: host}, nil
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(username, password), nil
+	}
+	return nil, fmt.Errorf("unsupported smtp auth mechanism: %s", mechanism)
+}
+
+// loginAuth 标准库没有实现的AUTH LOGIN，部分企业邮箱只支持这种方式；不保存状态，同一个实例可以被多个连接同时使用
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next 按服务器的提示回答用户名或密码，兼容 "User Name" 之类不标准的提示语
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(string(fromServer))
+	switch {
+	case strings.Contains(prompt, "user"):
+		return []byte(a.username), nil
+	case strings.Contains(prompt, "pass"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	if name == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(name)
+	return ip != nil && ip.IsLoopback()
+}
@@ -2,265 +2,190 @@ package component
 
 import (
 	"fmt"
-	"math"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var mapLock = sync.Mutex{}      // 对map加锁
-var locks = map[string]*confs{} // 对时间戳+machineId加锁
+// 上一次最新的时间戳，毫秒
+var lastT = atomic.Int64{}
 
-// 上一次最新的时间戳
-type lastTimestamp struct {
-	sync.Mutex       // 读写锁，更新的时候不能读，读的时候不能更新
-	t          int64 // 时间戳数值
-}
+// 每个machineId一组计数器，按 (时间戳, 序列号) 打包后CAS更新
+var counters = sync.Map{} // map[string]*machineCounters
 
-var lastT = lastTimestamp{Mutex: sync.Mutex{}}
+type machineCounters struct {
+	milli  seqCounter // 长格式，同一毫秒内的序列号
+	second seqCounter // 短格式，同一秒内的序列号
+}
 
-var clear = &task{
-	Mutex: sync.Mutex{},
-	doing: false,
+// seqCounter 高位为时间戳，低shift位为序列号
+type seqCounter struct {
+	state atomic.Uint64
+	shift uint8
 }
 
-type task struct {
-	sync.Mutex
-	doing bool
+const (
+	milliSeqShift  = 22 // 毫秒时间戳42位可以用到2109年，同一毫秒最多400万个
+	secondSeqShift = 32
+	idMark         = 0 // 末尾的标记位，目前固定为0
+)
+
+func getMachineCounters(machineId string) *machineCounters {
+	if c, ok := counters.Load(machineId); ok {
+		return c.(*machineCounters)
+	}
+	c, _ := counters.LoadOrStore(machineId, &machineCounters{
+		milli:  seqCounter{shift: milliSeqShift},
+		second: seqCounter{shift: secondSeqShift},
+	})
+	return c.(*machineCounters)
 }
 
-type confs struct {
-	*sync.Mutex
-	seq  int // 逻辑时钟，如果时间戳相等，那么就用内部的逻辑时钟
-	mark int
-	t    string
+// next 序列号从1开始，并发时时间戳取两者中较大的一个；maxSeq为0表示不限制，
+// 超过maxSeq时返回false，序列号占满时借用下一个时间戳
+func (c *seqCounter) next(ts int64, maxSeq uint64) (int64, uint64, bool) {
+	mask := uint64(1)<<c.shift - 1
+	for {
+		old := c.state.Load()
+		lastTs, seq := int64(old>>c.shift), old&mask
+		if ts < lastTs {
+			ts = lastTs
+		}
+		if ts == lastTs {
+			seq++
+		} else {
+			seq = 1
+		}
+		if maxSeq > 0 && seq > maxSeq {
+			return ts, 0, false
+		}
+		if seq > mask {
+			ts, seq = lastTs+1, 1
+		}
+		if c.state.CompareAndSwap(old, uint64(ts)<<c.shift|seq) {
+			return ts, seq, true
+		}
+	}
 }
 
 // 0～11bit	12bits	序列号，用来对同一个毫秒之内产生不同的ID，可记录4095个
 // 12～21bit	10bits	10bit用来记录机器ID，总共可以记录1024台机器
 // 22～62bit	41bits	用来记录时间戳，这里可以记录69年
 // 63bit	1bit	符号位，不做处理
+// 注意：下面的字符串ID并没有按照上述位布局打包，需要int64 ID时请使用 Generator
 
 // CreateSnowflakeIdV2 三台机器 每台qps 10W -> 1ms 100w条
 func CreateSnowflakeIdV2(machineId string) (string, error) {
-	if !clear.doing {
-		clear.Mutex.Lock()
-		// 也是保证时间回拨的一个机制
-		go clearExpiredConf()
-		clear.doing = true
-		clear.Mutex.Unlock()
-	}
-	// 准备信息
-	now := time.Now()
-
 	// 防止时钟回拨，只允许比上一个时间戳大的存在
-	now, err := checkClockBack(now)
+	now, err := checkClockBack()
 	if err != nil {
 		return "", err
 	}
 
-	milli := strconv.Itoa(int(now.UnixMilli()))
-	key := milli + machineId
-
-	// 同一毫秒加锁
-	mapLock.Lock()
-	conf, ok := locks[key]
-	if !ok {
-		conf = &confs{
-			Mutex: &sync.Mutex{},
-			seq:   0,
-			mark:  0,
-			t:     milli,
-		}
-		locks[key] = conf
-	}
-	conf.Lock()
-	defer conf.Unlock()
-
-	mapLock.Unlock()
-
-	// 序列自增
-	conf.seq += 1
-	// 固定位数
-	if conf.seq >= int(math.Pow10(12)) {
-		// 休眠一秒，继续生成
-		time.Sleep(time.Millisecond)
-		return CreateShortSnowflakeIdV2(machineId)
-	}
-	// 固定位数
-	se := fmt.Sprintf("%.12d", conf.seq)
+	milli, seq, _ := getMachineCounters(machineId).milli.next(now.UnixMilli(), 0)
 	// 生成唯一id
-	return milli + machineId + se + strconv.Itoa(conf.mark), nil
+	return formatLongId(milli, machineId, seq), nil
 }
 
-// 检查是否发生了始终回拨
-func checkClockBack(now time.Time) (time.Time, error) {
-	n := 0
-	lastT.Mutex.Lock()
-	defer lastT.Mutex.Unlock()
+// 检查是否发生了始终回拨，按 SetDefaultRollbackPolicy 设置的策略处理
+func checkClockBack() (time.Time, error) {
+	hw := legacyHighWater.Load()
+	if hw != nil {
+		if err := hw.ready(unixMilli); err != nil {
+			return time.Time{}, err
+		}
+	}
 	for {
-		// 增加写锁，一旦被接受，就要更新last时间戳
-		if now.UnixMilli() >= lastT.t {
-			now = time.Now() // 更新时间戳
-			lastT.t = now.UnixMilli()
-			break
-		} else if n >= 100 {
-			return time.Time{}, fmt.Errorf("generate id failed, clock back happened")
+		last := lastT.Load()
+		// 先读上一次的时间戳再取当前时间，并发时不会把别人刚写入的时间戳误判为回拨
+		ms := time.Now().UnixMilli()
+		if ms < last {
+			var err error
+			ms, err = getLegacyRollbackGuard().resolve(ms, last, unixMilli)
+			if err != nil {
+				return time.Time{}, err
+			}
+		}
+		if hw != nil {
+			if err := hw.reserve(ms); err != nil {
+				return time.Time{}, err
+			}
+		}
+		if ms == last || lastT.CompareAndSwap(last, ms) {
+			return time.UnixMilli(ms), nil
 		}
-		n += 1
 	}
-	return now, nil
+}
+
+func unixMilli() (int64, error) {
+	return time.Now().UnixMilli(), nil
 }
 
 // CreateShortSnowflakeIdV2 短位生成
 func CreateShortSnowflakeIdV2(machineId string) (string, error) {
-	if !clear.doing {
-		clear.Mutex.Lock()
-		go clearExpiredConf()
-		clear.doing = true
-		clear.Mutex.Unlock()
-	}
-	// 准备信息
-	now := time.Now()
-
 	// 防止时钟回拨，只允许比上一个时间戳大的存在
-	now, err := checkClockBack(now)
+	now, err := checkClockBack()
 	if err != nil {
 		return "", err
 	}
 
-	//second := strconv.Itoa(int(now.Unix()))
-	key := now.Format("20060102150405") + machineId
-
-	// 同一秒加锁
-	mapLock.Lock()
-
-	conf, ok := locks[key]
-	if !ok {
-		conf = &confs{
-			Mutex: &sync.Mutex{},
-			seq:   0,
-			mark:  0,
-			t:     strconv.Itoa(int(now.Unix())),
-		}
-		locks[key] = conf
-	}
-	conf.Lock()
-	defer conf.Unlock()
-
-	mapLock.Unlock()
-
-	// 序列自增
-	conf.seq += 1
-	// 固定位数 这里是3位，也就是说同一时间戳内可以生成1000个id
-	if conf.seq >= int(math.Pow10(3)) {
-		// 休眠一秒，继续生成
-		time.Sleep(time.Millisecond)
-		return CreateShortSnowflakeIdV2(machineId)
-	}
-	se := fmt.Sprintf("%.3d", conf.seq)
-	// 生成唯一id
-	return key + se + strconv.Itoa(conf.mark), nil
-}
-
-// ClearExpiredConf clear expired conf from map
-func clearExpiredConf() {
-	// check again
-	if clear.doing {
-		return
-	}
+	c := getMachineCounters(machineId)
 	for {
-		// clear map each 10 second
-		time.Sleep(time.Second * 10)
-		for k, v := range locks {
-			if !v.TryLock() { // in use
-				continue
-			}
-			unixTime, err := strconv.Atoi(v.t)
-			if err != nil {
-				continue
-			}
-			uti := time.UnixMilli(int64(unixTime))
-			// 10s的时钟回拨？吓死人。
-			if time.Now().After(uti.Add(time.Second * 10)) {
-				delete(locks, k)
-			}
+		// 固定位数 这里是3位，也就是说同一秒内可以生成999个id
+		second, seq, ok := c.second.next(now.Unix(), 999)
+		if ok {
+			return formatShortId(second, machineId, seq), nil
+		}
+		// 休眠到下一秒，继续生成
+		time.Sleep(time.Until(time.Unix(second+1, 0)))
+		if now, err = checkClockBack(); err != nil {
+			return "", err
 		}
 	}
 }
 
 // CreateSnowflakeId 三台机器 每台qps 10W -> 1ms 100w条
 func CreateSnowflakeId(machineId string) string {
-	if !clear.doing {
-		clear.Mutex.Lock()
-		go clearExpiredConf()
-		clear.doing = true
-		clear.Mutex.Unlock()
-	}
-	// 准备信息
-	now := time.Now()
-	milli := strconv.Itoa(int(now.UnixMilli()))
-	key := milli + machineId
-
-	// 同一毫秒加锁
-	mapLock.Lock()
-	conf, ok := locks[key]
-	if !ok {
-		conf = &confs{
-			Mutex: &sync.Mutex{},
-			seq:   0,
-			mark:  0,
-			t:     milli,
-		}
-		locks[key] = conf
+	// 准备信息，无法返回错误，回拨处理失败时沿用上一次的时间戳
+	now, err := checkClockBack()
+	if err != nil {
+		now = time.UnixMilli(lastT.Load())
 	}
-	conf.Lock()
-	defer conf.Unlock()
 
-	mapLock.Unlock()
-
-	// 序列自增
-	conf.seq += 1
-	// 固定位数
-	se := fmt.Sprintf("%.12d", conf.seq)
+	milli, seq, _ := getMachineCounters(machineId).milli.next(now.UnixMilli(), 0)
 	// 生成唯一id
-	return milli + machineId + se + strconv.Itoa(conf.mark)
+	return formatLongId(milli, machineId, seq)
 }
 
 // CreateShortSnowflakeId 短位生成
 func CreateShortSnowflakeId(machineId string) string {
-	if !clear.doing {
-		clear.Mutex.Lock()
-		go clearExpiredConf()
-		clear.doing = true
-		clear.Mutex.Unlock()
+	// 准备信息，无法返回错误，回拨处理失败时沿用上一次的时间戳
+	now, err := checkClockBack()
+	if err != nil {
+		now = time.UnixMilli(lastT.Load())
 	}
-	// 准备信息
-	now := time.Now()
-	//second := strconv.Itoa(int(now.Unix()))
-	key := now.Format("20060102150405") + machineId
 
-	// 同一秒加锁
-	mapLock.Lock()
-	conf, ok := locks[key]
-	if !ok {
-		conf = &confs{
-			Mutex: &sync.Mutex{},
-			seq:   0,
-			mark:  0,
-			t:     strconv.Itoa(int(now.Unix())),
+	c := getMachineCounters(machineId)
+	for {
+		// 序列号固定3位，同一秒内超过999个时等到下一秒，否则多出的位数会让ID无法解析
+		second, seq, ok := c.second.next(now.Unix(), 999)
+		if ok {
+			return formatShortId(second, machineId, seq)
+		}
+		time.Sleep(time.Until(time.Unix(second+1, 0)))
+		if now, err = checkClockBack(); err != nil {
+			now = time.Unix(second+1, 0)
 		}
-		locks[key] = conf
 	}
-	conf.Lock()
-	defer conf.Unlock()
+}
 
-	mapLock.Unlock()
+// 毫秒时间戳 + machineId + 12位序列号 + 标记位
+func formatLongId(milli int64, machineId string, seq uint64) string {
+	return strconv.FormatInt(milli, 10) + machineId + fmt.Sprintf("%.12d", seq) + strconv.Itoa(idMark)
+}
 
-	// 序列自增
-	conf.seq += 1
-	// 固定位数
-	se := fmt.Sprintf("%.3d", conf.seq)
-	// 生成唯一id
-	return key + se + strconv.Itoa(conf.mark)
+// yyyyMMddHHmmss + machineId + 3位序列号 + 标记位
+func formatShortId(second int64, machineId string, seq uint64) string {
+	return time.Unix(second, 0).Format(shortTimeFormat) + machineId + fmt.Sprintf("%.3d", seq) + strconv.Itoa(idMark)
 }
@@ -0,0 +1,387 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEpoch 默认纪元，41位毫秒时间戳从2020年开始可以用到2089年
+var DefaultEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultLayout 1bit符号位 | 41bits时间戳 | 10bits机器ID | 12bits序列号
+var DefaultLayout = Layout{TimestampBits: 41, WorkerBits: 10, SequenceBits: 12}
+
+var (
+	ErrInvalidLayout       = errors.New("invalid snowflake layout")
+	ErrWorkerIdOutOfRange  = errors.New("worker id out of layout range")
+	ErrShardOutOfRange     = errors.New("shard out of layout range")
+	ErrSequenceOverflow    = errors.New("sequence overflow in current millisecond")
+	ErrTimestampOverflow   = errors.New("timestamp exceeds layout capacity")
+	ErrClockBeforeEpoch    = errors.New("clock is before generator epoch")
+	ErrClockMovedBackwards = errors.New("generate id failed, clock back happened")
+	ErrBatchTooLarge       = errors.New("id batch too large")
+)
+
+// MaxBatchSize Reserve、NextN 单次最多生成的ID数量
+const MaxBatchSize = 100000
+
+// Layout int64 ID的位布局，高位到低位依次为 时间戳 | 分片 | 机器ID | 序列号，总位数不能超过63（最高位为符号位）；
+// ShardBits为0时没有分片字段
+type Layout struct {
+	TimestampBits uint8
+	ShardBits     uint8
+	WorkerBits    uint8
+	SequenceBits  uint8
+}
+
+func (l Layout) validate() error {
+	if l.TimestampBits == 0 || l.SequenceBits == 0 {
+		return fmt.Errorf("%w: timestamp and sequence bits must be positive", ErrInvalidLayout)
+	}
+	if l.totalBits() > 63 {
+		return fmt.Errorf("%w: total bits must not exceed 63", ErrInvalidLayout)
+	}
+	return nil
+}
+
+func (l Layout) totalBits() int {
+	return int(l.TimestampBits) + int(l.ShardBits) + int(l.WorkerBits) + int(l.SequenceBits)
+}
+
+// MaxTimestamp 相对纪元的最大毫秒数
+func (l Layout) MaxTimestamp() int64 {
+	return 1<<l.TimestampBits - 1
+}
+
+// MaxShard 最大分片号
+func (l Layout) MaxShard() int64 {
+	return 1<<l.ShardBits - 1
+}
+
+// ShardOf 取出ID中的分片号，只要知道布局，不需要生成器也能从主键路由到分片
+func (l Layout) ShardOf(id int64) int64 {
+	return id >> (l.WorkerBits + l.SequenceBits) & l.MaxShard()
+}
+
+// MaxWorkerId 最大机器ID
+func (l Layout) MaxWorkerId() int64 {
+	return 1<<l.WorkerBits - 1
+}
+
+// MaxSequence 同一毫秒内的最大序列号
+func (l Layout) MaxSequence() int64 {
+	return 1<<l.SequenceBits - 1
+}
+
+// OverflowPolicy 同一毫秒内序列号用尽时的处理方式
+type OverflowPolicy int
+
+const (
+	OverflowWait  OverflowPolicy = iota // 等待下一毫秒继续生成
+	OverflowError                       // 直接返回ErrSequenceOverflow
+)
+
+// Generator 按位打包的int64雪花ID生成器，生成的ID按时间有序，可以直接存入BIGINT列
+type Generator struct {
+	epoch    time.Time
+	layout   Layout
+	workerId int64
+	shard    int64 // NextId 使用的默认分片
+	overflow OverflowPolicy
+	lease    *WorkerLease // 机器ID租约，为空时表示机器ID由调用方保证唯一
+	clock    Clock
+	rollback *rollbackGuard
+	// 持久化已发放的最大时间戳，为空时表示不持久化
+	highWater *highWater
+
+	// 高位为上一次生成ID的时间戳（相对纪元的毫秒），低SequenceBits位为序列号，通过CAS更新
+	state atomic.Uint64
+}
+
+type GeneratorOption func(g *Generator)
+
+func applyGeneratorOptions(g *Generator, opts ...GeneratorOption) {
+	for _, o := range opts {
+		o(g)
+	}
+}
+
+// WithEpoch 设置纪元，纪元之后的毫秒数写入时间戳字段
+func WithEpoch(epoch time.Time) GeneratorOption {
+	return func(g *Generator) {
+		g.epoch = epoch
+	}
+}
+
+// WithLayout 设置时间戳、机器ID、序列号的位数
+func WithLayout(layout Layout) GeneratorOption {
+	return func(g *Generator) {
+		g.layout = layout
+	}
+}
+
+// WithShard 设置 NextId、NextN 使用的默认分片，需要布局中有分片字段
+func WithShard(shard int64) GeneratorOption {
+	return func(g *Generator) {
+		g.shard = shard
+	}
+}
+
+// WithOverflowPolicy 设置序列号溢出时的处理方式
+func WithOverflowPolicy(policy OverflowPolicy) GeneratorOption {
+	return func(g *Generator) {
+		g.overflow = policy
+	}
+}
+
+// WithClock 设置时钟，默认为系统时钟
+func WithClock(clock Clock) GeneratorOption {
+	return func(g *Generator) {
+		g.clock = clock
+	}
+}
+
+// WithRollbackPolicy 设置时钟回拨策略，默认等待时钟追上
+func WithRollbackPolicy(policy RollbackPolicy) GeneratorOption {
+	return func(g *Generator) {
+		g.rollback = newRollbackGuard(policy)
+	}
+}
+
+// NewGenerator workerId 必须在布局允许的范围内，不同实例之间不能重复
+func NewGenerator(workerId int64, opts ...GeneratorOption) (*Generator, error) {
+	g := &Generator{
+		epoch:    DefaultEpoch,
+		layout:   DefaultLayout,
+		workerId: workerId,
+		overflow: OverflowWait,
+		clock:    SystemClock,
+		rollback: newRollbackGuard(DefaultRollbackPolicy),
+	}
+	applyGeneratorOptions(g, opts...)
+
+	if err := g.layout.validate(); err != nil {
+		return nil, err
+	}
+	if workerId < 0 || workerId > g.layout.MaxWorkerId() {
+		return nil, fmt.Errorf("%w: %d not in [0, %d]", ErrWorkerIdOutOfRange,
+			workerId, g.layout.MaxWorkerId())
+	}
+	if err := g.checkShard(g.shard); err != nil {
+		return nil, err
+	}
+	if g.highWater != nil {
+		if err := g.highWater.cfg.validate(); err != nil {
+			return nil, err
+		}
+		if err := g.highWater.restore(g.epoch.UnixMilli(), nil); err != nil {
+			return nil, err
+		}
+		// 持久化的时间戳视为已经用尽，之后生成的ID都在它之后
+		g.state.Store(g.pack(g.highWater.start, g.layout.MaxSequence()))
+	}
+
+	return g, nil
+}
+
+// NextId 生成下一个ID，无锁且不分配内存，可以在每个请求上调用
+func (g *Generator) NextId() (int64, error) {
+	if err := g.ready(); err != nil {
+		return 0, err
+	}
+
+	return g.nextId(g.shard)
+}
+
+// NextIdForShard 生成属于指定分片的ID
+func (g *Generator) NextIdForShard(shard int64) (int64, error) {
+	if err := g.checkShard(shard); err != nil {
+		return 0, err
+	}
+	if err := g.ready(); err != nil {
+		return 0, err
+	}
+	return g.nextId(shard)
+}
+
+// NextIdColocated 生成与parentId同一分片的ID，比如订单ID和用户ID落在同一个库
+func (g *Generator) NextIdColocated(parentId int64) (int64, error) {
+	if parentId < 0 {
+		return 0, fmt.Errorf("%w: negative parent id %d", ErrMalformedId, parentId)
+	}
+	return g.NextIdForShard(g.ShardOf(parentId))
+}
+
+// ShardOf 取出ID中的分片号
+func (g *Generator) ShardOf(id int64) int64 {
+	return g.layout.ShardOf(id)
+}
+
+func (g *Generator) nextId(shard int64) (int64, error) {
+	ms, seq, _, err := g.take(1, g.overflow == OverflowWait)
+	if err != nil {
+		return 0, err
+	}
+	return g.compose(ms, shard, seq), nil
+}
+
+func (g *Generator) checkShard(shard int64) error {
+	if shard < 0 || shard > g.layout.MaxShard() {
+		return fmt.Errorf("%w: %d not in [0, %d]", ErrShardOutOfRange, shard, g.layout.MaxShard())
+	}
+	return nil
+}
+
+// IdBlock 同一毫秒内连续的一段ID，包含 [Start, Start+Count)
+type IdBlock struct {
+	Start int64 `json:"start"`
+	Count int   `json:"count"`
+}
+
+// Reserve 一次性预留n个ID，按时间顺序返回若干段连续的ID；当前毫秒的序列号不够时跨到下一毫秒继续分配，
+// 不受 OverflowError 影响；n超过 MaxBatchSize 时返回 ErrBatchTooLarge
+func (g *Generator) Reserve(n int) ([]IdBlock, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid id count %d", n)
+	}
+	if n > MaxBatchSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrBatchTooLarge, n, MaxBatchSize)
+	}
+	if err := g.ready(); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]IdBlock, 0, n/int(g.layout.MaxSequence()+1)+1)
+	for remain := int64(n); remain > 0; {
+		ms, start, count, err := g.take(remain, true)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, IdBlock{Start: g.compose(ms, g.shard, start), Count: int(count)})
+		remain -= count
+	}
+	return blocks, nil
+}
+
+// NextN 一次性生成n个单调递增的ID，n不能超过 MaxBatchSize
+func (g *Generator) NextN(n int) ([]int64, error) {
+	blocks, err := g.Reserve(n)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, n)
+	for _, b := range blocks {
+		for i := 0; i < b.Count; i++ {
+			ids = append(ids, b.Start+int64(i))
+		}
+	}
+	return ids, nil
+}
+
+// ready 租约有效并且时钟已经超过持久化的时间戳才可以发号
+func (g *Generator) ready() error {
+	if g.lease != nil && !g.lease.Valid() {
+		return ErrWorkerLeaseLost
+	}
+	if g.highWater != nil {
+		return g.highWater.ready(g.currentMs)
+	}
+	return nil
+}
+
+// take 在同一毫秒内取最多want个连续的序列号，返回时间戳、起始序列号和实际数量；
+// 当前毫秒已经用尽时，wait为true则切换到下一毫秒，否则返回ErrSequenceOverflow
+func (g *Generator) take(want int64, wait bool) (int64, int64, int64, error) {
+	for {
+		old := g.state.Load()
+		lastMs, seq := g.unpack(old)
+
+		now, err := g.currentMs()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		// 只允许不小于上一个时间戳的存在
+		if now < lastMs {
+			if now, err = g.rollback.resolve(now, lastMs, g.currentMs); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+
+		start := int64(0)
+		if now == lastMs {
+			start = seq + 1
+			if start > g.layout.MaxSequence() {
+				if !wait {
+					return 0, 0, 0, ErrSequenceOverflow
+				}
+				if now, err = g.rollback.advance(lastMs, g.currentMs); err != nil {
+					return 0, 0, 0, err
+				}
+				start = 0
+			}
+		}
+
+		if now > g.layout.MaxTimestamp() {
+			return 0, 0, 0, ErrTimestampOverflow
+		}
+		if g.highWater != nil {
+			if err = g.highWater.reserve(now); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+		count := g.layout.MaxSequence() - start + 1
+		if count > want {
+			count = want
+		}
+		// 被其他goroutine抢先更新时重试
+		if g.state.CompareAndSwap(old, g.pack(now, start+count-1)) {
+			return now, start, count, nil
+		}
+	}
+}
+
+// RollbackStats 时钟回拨策略的触发次数
+func (g *Generator) RollbackStats() RollbackStats {
+	return g.rollback.stats()
+}
+
+// Epoch 纪元
+func (g *Generator) Epoch() time.Time {
+	return g.epoch
+}
+
+// Layout 位布局
+func (g *Generator) Layout() Layout {
+	return g.layout
+}
+
+// WorkerId 机器ID
+func (g *Generator) WorkerId() int64 {
+	return g.workerId
+}
+
+func (g *Generator) pack(ms, seq int64) uint64 {
+	return uint64(ms)<<g.layout.SequenceBits | uint64(seq)
+}
+
+func (g *Generator) unpack(state uint64) (int64, int64) {
+	return int64(state >> g.layout.SequenceBits), int64(state) & g.layout.MaxSequence()
+}
+
+func (g *Generator) compose(ms, shard, seq int64) int64 {
+	return ms<<(g.layout.ShardBits+g.layout.WorkerBits+g.layout.SequenceBits) |
+		shard<<(g.layout.WorkerBits+g.layout.SequenceBits) |
+		g.workerId<<g.layout.SequenceBits |
+		seq
+}
+
+// 相对纪元的当前毫秒数
+func (g *Generator) currentMs() (int64, error) {
+	ms := g.clock.Now().Sub(g.epoch).Milliseconds()
+	if ms < 0 {
+		return 0, ErrClockBeforeEpoch
+	}
+	return ms, nil
+}
@@ -0,0 +1,296 @@
+package component
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGeneratorNextId(t *testing.T) {
+	g, err := NewGenerator(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 20000
+	ids := make([][]int64, workers)
+	group := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			for j := 0; j < perWorker; j++ {
+				id, err := g.NextId()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids[i] = append(ids[i], id)
+			}
+		}(i)
+	}
+	group.Wait()
+
+	seen := make(map[int64]struct{}, workers*perWorker)
+	for _, part := range ids {
+		for j, id := range part {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("repeat id %d", id)
+			}
+			seen[id] = struct{}{}
+			// 同一个goroutine内严格递增
+			if j > 0 && id <= part[j-1] {
+				t.Fatalf("id %d not greater than previous %d", id, part[j-1])
+			}
+			if worker := id >> DefaultLayout.SequenceBits & DefaultLayout.MaxWorkerId(); worker != 7 {
+				t.Fatalf("unexpected worker id %d", worker)
+			}
+		}
+	}
+}
+
+func TestGeneratorCustomLayout(t *testing.T) {
+	layout := Layout{TimestampBits: 40, WorkerBits: 4, SequenceBits: 2}
+	epoch := time.Now().Add(-time.Hour)
+	g, err := NewGenerator(15, WithLayout(layout), WithEpoch(epoch))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last int64
+	for i := 0; i < 100; i++ {
+		id, err := g.NextId()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not greater than previous %d", id, last)
+		}
+		last = id
+	}
+
+	ms := last >> (layout.WorkerBits + layout.SequenceBits)
+	if elapsed := time.Since(epoch).Milliseconds(); ms > elapsed || elapsed-ms > 1000 {
+		t.Fatalf("unexpected timestamp %d, elapsed %d", ms, elapsed)
+	}
+}
+
+func TestGeneratorOverflowError(t *testing.T) {
+	layout := Layout{TimestampBits: 41, WorkerBits: 0, SequenceBits: 1}
+	g, err := NewGenerator(0, WithLayout(layout), WithOverflowPolicy(OverflowError))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overflowed := false
+	for i := 0; i < 1000 && !overflowed; i++ {
+		if _, err = g.NextId(); errors.Is(err, ErrSequenceOverflow) {
+			overflowed = true
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !overflowed {
+		t.Fatal("expected sequence overflow with 1 sequence bit")
+	}
+}
+
+func TestNewGeneratorInvalid(t *testing.T) {
+	if _, err := NewGenerator(1024); !errors.Is(err, ErrWorkerIdOutOfRange) {
+		t.Fatalf("expected ErrWorkerIdOutOfRange, got %v", err)
+	}
+	if _, err := NewGenerator(0, WithLayout(Layout{TimestampBits: 41, WorkerBits: 12, SequenceBits: 12})); !errors.Is(err, ErrInvalidLayout) {
+		t.Fatalf("expected ErrInvalidLayout, got %v", err)
+	}
+	if _, err := NewGenerator(0, WithEpoch(time.Now().Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkGeneratorNextId(b *testing.B) {
+	g, err := NewGenerator(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err = g.NextId(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 默认布局每毫秒4096个，高并发下吞吐上限为每秒约400万
+func BenchmarkGeneratorNextIdParallel(b *testing.B) {
+	g, err := NewGenerator(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := g.NextId(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// 序列号位数足够时不受每毫秒上限影响，体现CAS本身的开销
+func BenchmarkGeneratorNextIdParallelWideSequence(b *testing.B) {
+	g, err := NewGenerator(0, WithLayout(Layout{TimestampBits: 41, WorkerBits: 0, SequenceBits: 22}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := g.NextId(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkCreateSnowflakeIdParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			CreateSnowflakeId("0")
+		}
+	})
+}
+
+func TestGeneratorNextN(t *testing.T) {
+	g, err := NewGenerator(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与单个生成并发，不能出现重复
+	single := make(chan int64, 1000)
+	go func() {
+		defer close(single)
+		for i := 0; i < 1000; i++ {
+			id, err := g.NextId()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			single <- id
+		}
+	}()
+
+	const n = 3*4096 + 100
+	ids, err := g.NextN(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != n {
+		t.Fatalf("expected %d ids, got %d", n, len(ids))
+	}
+	seen := make(map[int64]struct{}, n+1000)
+	for i, id := range ids {
+		if i > 0 && id <= ids[i-1] {
+			t.Fatalf("id %d not greater than previous %d", id, ids[i-1])
+		}
+		seen[id] = struct{}{}
+	}
+	for id := range single {
+		if _, ok := seen[id]; ok {
+			t.Fatalf("repeat id %d", id)
+		}
+		seen[id] = struct{}{}
+	}
+
+	if _, err = g.NextN(MaxBatchSize + 1); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+}
+
+func TestGeneratorReserve(t *testing.T) {
+	layout := Layout{TimestampBits: 41, WorkerBits: 10, SequenceBits: 4}
+	g, err := NewGenerator(9, WithLayout(layout), WithOverflowPolicy(OverflowError))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := g.Reserve(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) < 100/16 {
+		t.Fatalf("expected blocks spanning milliseconds, got %d", len(blocks))
+	}
+	total := 0
+	for i, b := range blocks {
+		total += b.Count
+		start, err := g.Decode(b.Start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := g.Decode(b.Start + int64(b.Count) - 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 每一段都在同一毫秒内
+		if !start.Time.Equal(end.Time) || end.Sequence-start.Sequence != int64(b.Count-1) {
+			t.Fatalf("block %+v is not contiguous", b)
+		}
+		if i > 0 && b.Start <= blocks[i-1].Start+int64(blocks[i-1].Count-1) {
+			t.Fatalf("block %+v overlaps previous block", b)
+		}
+	}
+	if total != 100 {
+		t.Fatalf("expected 100 ids, got %d", total)
+	}
+
+	if blocks, err = g.Reserve(0); err != nil || len(blocks) != 0 {
+		t.Fatalf("unexpected reserve result %v, %v", blocks, err)
+	}
+}
+
+func TestGeneratorShard(t *testing.T) {
+	layout := Layout{TimestampBits: 41, ShardBits: 6, WorkerBits: 5, SequenceBits: 11}
+	users, err := NewGenerator(3, WithLayout(layout), WithShard(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := NewGenerator(7, WithLayout(layout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, err := users.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shard := layout.ShardOf(userId); shard != 42 {
+		t.Fatalf("user id %d in shard %d, expected 42", userId, shard)
+	}
+	orderId, err := orders.NextIdColocated(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders.ShardOf(orderId) != 42 {
+		t.Fatalf("order id %d not co-located with user id %d", orderId, userId)
+	}
+
+	info, err := orders.Decode(orderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Shard != 42 || info.WorkerId != 7 {
+		t.Fatalf("unexpected decode result %+v", info)
+	}
+
+	if _, err = orders.NextIdForShard(64); !errors.Is(err, ErrShardOutOfRange) {
+		t.Fatalf("expected ErrShardOutOfRange, got %v", err)
+	}
+	if _, err = NewGenerator(1, WithShard(1)); !errors.Is(err, ErrShardOutOfRange) {
+		t.Fatalf("expected ErrShardOutOfRange without shard bits, got %v", err)
+	}
+}
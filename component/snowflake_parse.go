@@ -0,0 +1,156 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var ErrMalformedId = errors.New("malformed id")
+
+// IdFormat ID的格式
+type IdFormat int
+
+const (
+	IdFormatLong  IdFormat = iota + 1 // CreateSnowflakeId / CreateSnowflakeIdV2
+	IdFormatShort                     // CreateShortSnowflakeId / CreateShortSnowflakeIdV2
+	IdFormatInt64                     // Generator
+)
+
+func (f IdFormat) String() string {
+	switch f {
+	case IdFormatLong:
+		return "long"
+	case IdFormatShort:
+		return "short"
+	case IdFormatInt64:
+		return "int64"
+	default:
+		return "unknown"
+	}
+}
+
+// IdInfo ID解析后的各个组成部分
+type IdInfo struct {
+	Format    IdFormat  `json:"format"`
+	Time      time.Time `json:"time"`                 // 生成时间，短格式精确到秒
+	MachineId string    `json:"machine_id,omitempty"` // 字符串格式中的机器ID原文
+	Shard     int64     `json:"shard"`                // int64格式中的分片号
+	WorkerId  int64     `json:"worker_id"`            // int64格式中的机器ID
+	Sequence  int64     `json:"sequence"`
+	Mark      int       `json:"mark"` // 字符串格式末尾的标记位
+}
+
+const (
+	longTimeLen  = 13 // 毫秒时间戳位数，2286年之前都是13位
+	longSeqLen   = 12
+	shortTimeLen = len(shortTimeFormat)
+	shortSeqLen  = 3
+	markLen      = 1
+
+	shortTimeFormat = "20060102150405"
+)
+
+// ParseId 自动识别长、短两种字符串格式并解析，int64格式请使用 Generator.Decode 或 DecodeId
+func ParseId(id string) (*IdInfo, error) {
+	// 短格式以yyyyMMddHHmmss开头，长格式的毫秒时间戳在2033年之前都以1开头，不会被识别成日期
+	if len(id) >= shortTimeLen {
+		if t, err := time.ParseInLocation(shortTimeFormat, id[:shortTimeLen], time.Local); err == nil && t.Year() >= 2000 {
+			return ParseShortSnowflakeId(id)
+		}
+	}
+	return ParseSnowflakeId(id)
+}
+
+// ParseSnowflakeId 解析 CreateSnowflakeId 生成的长格式：毫秒时间戳 + machineId + 12位序列号 + 标记位
+func ParseSnowflakeId(id string) (*IdInfo, error) {
+	if len(id) < longTimeLen+longSeqLen+markLen {
+		return nil, fmt.Errorf("%w: %q is too short for long format", ErrMalformedId, id)
+	}
+	milli, err := parseDigits(id[:longTimeLen])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad timestamp in %q", ErrMalformedId, id)
+	}
+	info, err := parseTail(id[longTimeLen:], longSeqLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s in %q", ErrMalformedId, err.Error(), id)
+	}
+	info.Format = IdFormatLong
+	info.Time = time.UnixMilli(milli)
+	return info, nil
+}
+
+// ParseShortSnowflakeId 解析 CreateShortSnowflakeId 生成的短格式：yyyyMMddHHmmss + machineId + 3位序列号 + 标记位
+func ParseShortSnowflakeId(id string) (*IdInfo, error) {
+	if len(id) < shortTimeLen+shortSeqLen+markLen {
+		return nil, fmt.Errorf("%w: %q is too short for short format", ErrMalformedId, id)
+	}
+	t, err := time.ParseInLocation(shortTimeFormat, id[:shortTimeLen], time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad time in %q", ErrMalformedId, id)
+	}
+	info, err := parseTail(id[shortTimeLen:], shortSeqLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s in %q", ErrMalformedId, err.Error(), id)
+	}
+	info.Format = IdFormatShort
+	info.Time = t
+	return info, nil
+}
+
+// DecodeId 按指定的纪元和位布局拆分int64 ID
+func DecodeId(id int64, epoch time.Time, layout Layout) (*IdInfo, error) {
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	if id < 0 || id>>layout.totalBits() != 0 {
+		return nil, fmt.Errorf("%w: %d does not fit layout", ErrMalformedId, id)
+	}
+	ms := id >> (layout.ShardBits + layout.WorkerBits + layout.SequenceBits)
+	return &IdInfo{
+		Format:   IdFormatInt64,
+		Time:     epoch.Add(time.Duration(ms) * time.Millisecond),
+		Shard:    layout.ShardOf(id),
+		WorkerId: id >> layout.SequenceBits & layout.MaxWorkerId(),
+		Sequence: id & layout.MaxSequence(),
+	}, nil
+}
+
+// Decode 拆分由当前生成器配置生成的ID
+func (g *Generator) Decode(id int64) (*IdInfo, error) {
+	return DecodeId(id, g.epoch, g.layout)
+}
+
+// 解析 machineId + 定长序列号 + 标记位
+func parseTail(tail string, seqLen int) (*IdInfo, error) {
+	if len(tail) < seqLen+markLen {
+		return nil, errors.New("missing sequence")
+	}
+	markStart := len(tail) - markLen
+	seqStart := markStart - seqLen
+
+	seq, err := parseDigits(tail[seqStart:markStart])
+	if err != nil {
+		return nil, errors.New("bad sequence")
+	}
+	mark, err := parseDigits(tail[markStart:])
+	if err != nil {
+		return nil, errors.New("bad mark")
+	}
+	return &IdInfo{
+		MachineId: tail[:seqStart],
+		Sequence:  seq,
+		Mark:      int(mark),
+	}, nil
+}
+
+// strconv.ParseInt 允许正负号，这里只接受纯数字
+func parseDigits(s string) (int64, error) {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, strconv.ErrSyntax
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -0,0 +1,93 @@
+package component
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseId(t *testing.T) {
+	before := time.Now()
+
+	long := CreateSnowflakeId("node-1")
+	info, err := ParseId(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != IdFormatLong || info.MachineId != "node-1" || info.Sequence < 1 {
+		t.Fatalf("unexpected long id info %+v", info)
+	}
+	if d := info.Time.Sub(before); d < -time.Millisecond || d > time.Second {
+		t.Fatalf("unexpected long id time %v", info.Time)
+	}
+
+	short := CreateShortSnowflakeId("0118")
+	info, err = ParseId(short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != IdFormatShort || info.MachineId != "0118" || info.Sequence < 1 {
+		t.Fatalf("unexpected short id info %+v", info)
+	}
+	if d := info.Time.Sub(before.Truncate(time.Second)); d < 0 || d > time.Second {
+		t.Fatalf("unexpected short id time %v", info.Time)
+	}
+}
+
+func TestParseShortIdSequenceOverflow(t *testing.T) {
+	// 同一秒内超过999个之后的ID仍然可以正确解析
+	seen := make(map[string]bool, 1200)
+	for i := 0; i < 1200; i++ {
+		id := CreateShortSnowflakeId("node-overflow")
+		if seen[id] {
+			t.Fatalf("duplicate id %s", id)
+		}
+		seen[id] = true
+		info, err := ParseId(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.MachineId != "node-overflow" || info.Sequence < 1 || info.Sequence > 999 {
+			t.Fatalf("unexpected short id info %+v for %s", info, id)
+		}
+	}
+}
+
+func TestParseIdMalformed(t *testing.T) {
+	for _, id := range []string{
+		"",
+		"123",
+		"17x0000000000node000000000001" + "0",
+		"1760000000000node00000000000x0",
+		"20251018110438a00x0",
+	} {
+		if _, err := ParseId(id); !errors.Is(err, ErrMalformedId) {
+			t.Fatalf("expected ErrMalformedId for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestGeneratorDecode(t *testing.T) {
+	g, err := NewGenerator(321)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := g.Decode(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != IdFormatInt64 || info.WorkerId != 321 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if d := time.Since(info.Time); d < 0 || d > time.Second {
+		t.Fatalf("unexpected time %v", info.Time)
+	}
+
+	if _, err = g.Decode(-1); !errors.Is(err, ErrMalformedId) {
+		t.Fatalf("expected ErrMalformedId, got %v", err)
+	}
+}
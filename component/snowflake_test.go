@@ -5,7 +5,6 @@ import (
 	"net"
 	"sync"
 	"testing"
-	"time"
 )
 
 func TestCreateSnowflakeId(t *testing.T) {
@@ -19,7 +18,8 @@ func TestCreateSnowflakeId(t *testing.T) {
 
 	s := sync.Map{}
 	group := sync.WaitGroup{}
-	for i := 0; i < 100000; i++ {
+	// 短格式同一秒最多999个，3000个会跨过几秒
+	for i := 0; i < 3000; i++ {
 		group.Add(1)
 		go func() {
 			//id := CreateSnowflakeId(i.HardwareAddr.String())
@@ -41,19 +41,25 @@ func TestCreateSnowflakeId(t *testing.T) {
 	//println(id)
 }
 
-func TestOutF(t *testing.T) {
-	conf := &confs{
-		Mutex: &sync.Mutex{},
-		seq:   0,
-		mark:  0,
+func TestCreateSnowflakeIdV2Concurrent(t *testing.T) {
+	s := sync.Map{}
+	group := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 5000; j++ {
+				id, err := CreateSnowflakeIdV2("node")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, loaded := s.LoadOrStore(id, ""); loaded {
+					t.Error("repeat", id)
+					return
+				}
+			}
+		}()
 	}
-	conf.Lock()
-	defer conf.Unlock()
-	go func() {
-		//conf.Lock()
-		println(conf)
-		//conf.Unlock()
-	}()
-	println("123")
-	time.Sleep(5 * time.Second)
+	group.Wait()
 }
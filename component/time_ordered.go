@@ -0,0 +1,116 @@
+package component
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// timeOrdered ULID 和 UUIDv7 共用的单调时间源：毫秒时间戳 + 随机部分，
+// 同一毫秒内随机部分递增保证有序，时钟回拨与 Generator 一样按 RollbackPolicy 处理
+type timeOrdered struct {
+	clock    Clock
+	rollback *rollbackGuard
+	entropy  io.Reader
+	bits     uint // 随机部分的位数，最多80位
+
+	mu     sync.Mutex
+	lastMs int64
+	last   [10]byte // 上一次的随机部分，大端
+}
+
+type TimeOrderedOption func(t *timeOrdered)
+
+func applyTimeOrderedOptions(t *timeOrdered, opts ...TimeOrderedOption) {
+	for _, o := range opts {
+		o(t)
+	}
+}
+
+// WithTimeOrderedClock 设置时钟，默认为系统时钟
+func WithTimeOrderedClock(clock Clock) TimeOrderedOption {
+	return func(t *timeOrdered) {
+		t.clock = clock
+	}
+}
+
+// WithTimeOrderedRollbackPolicy 设置时钟回拨策略，默认等待时钟追上
+func WithTimeOrderedRollbackPolicy(policy RollbackPolicy) TimeOrderedOption {
+	return func(t *timeOrdered) {
+		t.rollback = newRollbackGuard(policy)
+	}
+}
+
+// WithEntropy 设置随机源，默认为crypto/rand
+func WithEntropy(entropy io.Reader) TimeOrderedOption {
+	return func(t *timeOrdered) {
+		t.entropy = entropy
+	}
+}
+
+func newTimeOrdered(bits uint, opts ...TimeOrderedOption) *timeOrdered {
+	t := &timeOrdered{
+		clock:    SystemClock,
+		rollback: newRollbackGuard(DefaultRollbackPolicy),
+		entropy:  rand.Reader,
+		bits:     bits,
+		lastMs:   -1,
+	}
+	applyTimeOrderedOptions(t, opts...)
+	return t
+}
+
+// next 返回毫秒时间戳和随机部分
+func (t *timeOrdered) next() (int64, [10]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now().UnixMilli()
+	var err error
+	if now < t.lastMs {
+		if now, err = t.rollback.resolve(now, t.lastMs, t.currentMs); err != nil {
+			return 0, [10]byte{}, err
+		}
+	}
+
+	if now == t.lastMs {
+		if t.increment() {
+			return t.lastMs, t.last, nil
+		}
+		// 随机部分溢出，切换到下一毫秒
+		if now, err = t.rollback.advance(t.lastMs, t.currentMs); err != nil {
+			return 0, [10]byte{}, err
+		}
+	}
+
+	if _, err = io.ReadFull(t.entropy, t.last[:]); err != nil {
+		return 0, [10]byte{}, err
+	}
+	// 最高位留出一位余量，避免刚开始就接近溢出
+	t.last[0] &= t.topMask() >> 1
+	t.lastMs = now
+	return t.lastMs, t.last, nil
+}
+
+func (t *timeOrdered) currentMs() (int64, error) {
+	return t.clock.Now().UnixMilli(), nil
+}
+
+// 随机部分最高字节的有效位
+func (t *timeOrdered) topMask() byte {
+	return byte(0xFF >> (80 - t.bits))
+}
+
+// increment 随机部分加一，溢出时返回false
+func (t *timeOrdered) increment() bool {
+	for i := len(t.last) - 1; i >= 0; i-- {
+		t.last[i]++
+		if t.last[i] != 0 {
+			break
+		}
+		if i == 0 {
+			return false
+		}
+	}
+	return t.last[0]&^t.topMask() == 0
+}
@@ -0,0 +1,143 @@
+package component
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestULIDGenerator(t *testing.T) {
+	clock := newManualClock()
+	g := NewULIDGenerator(WithTimeOrderedClock(clock))
+
+	// 同一毫秒内严格递增
+	var last ULID
+	for i := 0; i < 1000; i++ {
+		u, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && bytes.Compare(u[:], last[:]) <= 0 {
+			t.Fatalf("ulid %s not greater than previous %s", u, last)
+		}
+		if u.String() <= last.String() && i > 0 {
+			t.Fatalf("ulid text %s not greater than previous %s", u, last)
+		}
+		if !u.Time().Equal(clock.Now().Truncate(time.Millisecond)) {
+			t.Fatalf("unexpected time %v", u.Time())
+		}
+		last = u
+	}
+
+	parsed, err := ParseULID(strings.ToLower(last.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != last {
+		t.Fatalf("parsed %s, expected %s", parsed, last)
+	}
+
+	data, err := json.Marshal(struct{ Id ULID }{last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct{ Id ULID }
+	if err = json.Unmarshal(data, &decoded); err != nil || decoded.Id != last {
+		t.Fatalf("json round trip failed: %s, %v", data, err)
+	}
+
+	bin, _ := last.MarshalBinary()
+	var fromBin ULID
+	if err = fromBin.UnmarshalBinary(bin); err != nil || fromBin != last {
+		t.Fatalf("binary round trip failed: %v", err)
+	}
+
+	for _, s := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if ValidULID(s) {
+			t.Fatalf("expected %q to be invalid", s)
+		}
+	}
+	if !ValidULID("01ARZ3NDEKTSV4RRFFQ69G5FAV") {
+		t.Fatal("expected valid ulid")
+	}
+}
+
+func TestUUIDv7Generator(t *testing.T) {
+	clock := newManualClock()
+	g := NewUUIDv7Generator(WithTimeOrderedClock(clock))
+
+	var last UUID
+	for i := 0; i < 1000; i++ {
+		u, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && u.String() <= last.String() {
+			t.Fatalf("uuid %s not greater than previous %s", u, last)
+		}
+		if _, err = ParseUUIDv7(u.String()); err != nil {
+			t.Fatal(err)
+		}
+		last = u
+	}
+	if !last.Time().Equal(clock.Now().Truncate(time.Millisecond)) {
+		t.Fatalf("unexpected time %v", last.Time())
+	}
+
+	// RFC 9562 附录中的示例
+	u, err := ParseUUIDv7("017F22E2-79B0-7CC3-98C4-DC0C0C07398F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Time().UnixMilli() != 0x017F22E279B0 || u.String() != "017f22e2-79b0-7cc3-98c4-dc0c0c07398f" {
+		t.Fatalf("unexpected uuid %s at %v", u, u.Time())
+	}
+
+	if ValidUUIDv7("f81d4fae-7dec-11d0-a765-00a0c91e6bf6") {
+		t.Fatal("version 1 uuid should not be a valid v7")
+	}
+
+	data, err := last.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded UUID
+	if err = decoded.UnmarshalText(data); err != nil || decoded != last {
+		t.Fatalf("text round trip failed: %v", err)
+	}
+}
+
+func TestTimeOrderedRollback(t *testing.T) {
+	clock := newManualClock()
+	g := NewULIDGenerator(WithTimeOrderedClock(clock),
+		WithTimeOrderedRollbackPolicy(RollbackPolicy{Strategy: RollbackBorrow}))
+	first, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(-10 * time.Millisecond)
+	second, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.String() <= first.String() {
+		t.Fatalf("ulid %s not greater than previous %s", second, first)
+	}
+	if stats := g.RollbackStats(); stats.Borrows != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	failing := NewUUIDv7Generator(WithTimeOrderedClock(clock),
+		WithTimeOrderedRollbackPolicy(RollbackPolicy{Strategy: RollbackFail}))
+	if _, err = failing.Next(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-time.Millisecond)
+	if _, err = failing.Next(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("expected ErrClockMovedBackwards, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package component
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidULID = errors.New("invalid ulid")
+
+// crockfordAlphabet Crockford Base32字母表，去掉了I L O U
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const ulidEncodedLen = 26
+
+// crockfordValues 字符到数值的映射，非法字符为0xFF，大小写不敏感
+var crockfordValues = func() [256]byte {
+	var values [256]byte
+	for i := range values {
+		values[i] = 0xFF
+	}
+	for i := 0; i < len(crockfordAlphabet); i++ {
+		c := crockfordAlphabet[i]
+		values[c] = byte(i)
+		if c >= 'A' && c <= 'Z' {
+			values[c+'a'-'A'] = byte(i)
+		}
+	}
+	return values
+}()
+
+// ULID 48位毫秒时间戳 + 80位随机数，文本形式为26位Crockford Base32
+type ULID [16]byte
+
+// ULIDGenerator 同一毫秒内随机部分递增，保证生成的ULID严格有序
+type ULIDGenerator struct {
+	source *timeOrdered
+}
+
+func NewULIDGenerator(opts ...TimeOrderedOption) *ULIDGenerator {
+	return &ULIDGenerator{source: newTimeOrdered(80, opts...)}
+}
+
+// Next 生成下一个ULID
+func (g *ULIDGenerator) Next() (ULID, error) {
+	ms, random, err := g.source.next()
+	if err != nil {
+		return ULID{}, err
+	}
+	var u ULID
+	putUint48(u[:6], ms)
+	copy(u[6:], random[:])
+	return u, nil
+}
+
+// RollbackStats 时钟回拨策略的触发次数
+func (g *ULIDGenerator) RollbackStats() RollbackStats {
+	return g.source.rollback.stats()
+}
+
+var defaultULIDGenerator = NewULIDGenerator()
+
+// NewULID 使用默认生成器生成ULID
+func NewULID() (ULID, error) {
+	return defaultULIDGenerator.Next()
+}
+
+// ParseULID 解析26位Crockford Base32字符串，大小写不敏感
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if err := u.UnmarshalText([]byte(s)); err != nil {
+		return ULID{}, err
+	}
+	return u, nil
+}
+
+// ValidULID 判断字符串是否为合法的ULID
+func ValidULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+// Time ULID中的时间戳
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(getUint48(u[:6]))
+}
+
+func (u ULID) String() string {
+	text, _ := u.MarshalText()
+	return string(text)
+}
+
+func (u ULID) MarshalText() ([]byte, error) {
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+	text := make([]byte, ulidEncodedLen)
+	// 每次取最低5位，128位整体右移5位
+	for i := ulidEncodedLen - 1; i >= 0; i-- {
+		text[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return text, nil
+}
+
+func (u *ULID) UnmarshalText(text []byte) error {
+	if len(text) != ulidEncodedLen {
+		return fmt.Errorf("%w: length %d", ErrInvalidULID, len(text))
+	}
+	// 26*5=130位，第一个字符只能用低3位，否则超过128位
+	if v := crockfordValues[text[0]]; v == 0xFF || v > 7 {
+		return fmt.Errorf("%w: %q overflows 128 bits", ErrInvalidULID, text)
+	}
+	var hi, lo uint64
+	for _, c := range text {
+		v := crockfordValues[c]
+		if v == 0xFF {
+			return fmt.Errorf("%w: illegal character %q", ErrInvalidULID, c)
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return nil
+}
+
+func (u ULID) MarshalBinary() ([]byte, error) {
+	return u[:], nil
+}
+
+func (u *ULID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(u) {
+		return fmt.Errorf("%w: binary length %d", ErrInvalidULID, len(data))
+	}
+	copy(u[:], data)
+	return nil
+}
+
+func putUint48(b []byte, v int64) {
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func getUint48(b []byte) int64 {
+	var v int64
+	for i := 0; i < 6; i++ {
+		v = v<<8 | int64(b[i])
+	}
+	return v
+}
@@ -0,0 +1,151 @@
+package component
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+const uuidEncodedLen = 36
+
+// UUID RFC 9562 UUID，文本形式为 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+type UUID [16]byte
+
+// UUIDv7Generator 生成UUIDv7：48位毫秒时间戳 + 版本 + 12位rand_a + 变体 + 62位rand_b，
+// 同一毫秒内74位随机部分递增，保证严格有序
+type UUIDv7Generator struct {
+	source *timeOrdered
+}
+
+func NewUUIDv7Generator(opts ...TimeOrderedOption) *UUIDv7Generator {
+	return &UUIDv7Generator{source: newTimeOrdered(74, opts...)}
+}
+
+// Next 生成下一个UUIDv7
+func (g *UUIDv7Generator) Next() (UUID, error) {
+	ms, random, err := g.source.next()
+	if err != nil {
+		return UUID{}, err
+	}
+	// 74位随机数 = rand_a(12) | rand_b(62)
+	hi := uint64(random[0])<<8 | uint64(random[1])
+	lo := binary.BigEndian.Uint64(random[2:])
+	randA := hi<<2 | lo>>62
+	randB := lo & (1<<62 - 1)
+
+	var u UUID
+	putUint48(u[:6], ms)
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|uint16(randA))
+	binary.BigEndian.PutUint64(u[8:], 0x8000000000000000|randB)
+	return u, nil
+}
+
+// RollbackStats 时钟回拨策略的触发次数
+func (g *UUIDv7Generator) RollbackStats() RollbackStats {
+	return g.source.rollback.stats()
+}
+
+var defaultUUIDv7Generator = NewUUIDv7Generator()
+
+// NewUUIDv7 使用默认生成器生成UUIDv7
+func NewUUIDv7() (UUID, error) {
+	return defaultUUIDv7Generator.Next()
+}
+
+// ParseUUID 解析标准的36位格式或者不带连字符的32位十六进制格式
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if err := u.UnmarshalText([]byte(s)); err != nil {
+		return UUID{}, err
+	}
+	return u, nil
+}
+
+// ParseUUIDv7 解析并校验版本号为7、变体为RFC 9562
+func ParseUUIDv7(s string) (UUID, error) {
+	u, err := ParseUUID(s)
+	if err != nil {
+		return UUID{}, err
+	}
+	if u.Version() != 7 || u[8]&0xC0 != 0x80 {
+		return UUID{}, fmt.Errorf("%w: %q is not a version 7 uuid", ErrInvalidUUID, s)
+	}
+	return u, nil
+}
+
+// ValidUUIDv7 判断字符串是否为合法的UUIDv7
+func ValidUUIDv7(s string) bool {
+	_, err := ParseUUIDv7(s)
+	return err == nil
+}
+
+// Version 版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time UUIDv7中的时间戳，其它版本没有意义
+func (u UUID) Time() time.Time {
+	return time.UnixMilli(getUint48(u[:6]))
+}
+
+func (u UUID) String() string {
+	text, _ := u.MarshalText()
+	return string(text)
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	text := make([]byte, uuidEncodedLen)
+	hex.Encode(text[0:8], u[0:4])
+	text[8] = '-'
+	hex.Encode(text[9:13], u[4:6])
+	text[13] = '-'
+	hex.Encode(text[14:18], u[6:8])
+	text[18] = '-'
+	hex.Encode(text[19:23], u[8:10])
+	text[23] = '-'
+	hex.Encode(text[24:], u[10:])
+	return text, nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	var compact []byte
+	switch len(text) {
+	case uuidEncodedLen:
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return fmt.Errorf("%w: %q", ErrInvalidUUID, text)
+		}
+		compact = make([]byte, 0, 32)
+		compact = append(compact, text[0:8]...)
+		compact = append(compact, text[9:13]...)
+		compact = append(compact, text[14:18]...)
+		compact = append(compact, text[19:23]...)
+		compact = append(compact, text[24:]...)
+	case 32:
+		compact = text
+	default:
+		return fmt.Errorf("%w: length %d", ErrInvalidUUID, len(text))
+	}
+	var parsed UUID
+	if _, err := hex.Decode(parsed[:], compact); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidUUID, text)
+	}
+	*u = parsed
+	return nil
+}
+
+func (u UUID) MarshalBinary() ([]byte, error) {
+	return u[:], nil
+}
+
+func (u *UUID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(u) {
+		return fmt.Errorf("%w: binary length %d", ErrInvalidUUID, len(data))
+	}
+	copy(u[:], data)
+	return nil
+}
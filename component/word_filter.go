@@ -0,0 +1,254 @@
+package component
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nioliu/protocols/httpproto"
+)
+
+// 常见的繁体字到简体字，词库和待检查的内容都会转换成简体后再匹配，可以通过 WithWordVariants 补充
+var defaultWordVariants = map[rune]rune{
+	'國': '国', '們': '们', '說': '说', '這': '这', '個': '个', '來': '来', '對': '对', '時': '时',
+	'會': '会', '學': '学', '後': '后', '發': '发', '開': '开', '關': '关', '麼': '么', '點': '点',
+	'體': '体', '為': '为', '無': '无', '與': '与', '東': '东', '車': '车', '長': '长', '門': '门',
+	'問': '问', '間': '间', '見': '见', '頭': '头', '馬': '马', '魚': '鱼', '鳥': '鸟', '黨': '党',
+	'當': '当', '錢': '钱', '賣': '卖', '買': '买', '貨': '货', '賭': '赌', '槍': '枪', '殺': '杀',
+	'黃': '黄', '網': '网', '帳': '帐', '號': '号', '讓': '让', '認': '认', '識': '识', '話': '话',
+	'語': '语', '親': '亲', '愛': '爱', '氣': '气', '電': '电', '腦': '脑', '華': '华', '業': '业',
+	'線': '线', '紅': '红', '藥': '药', '傳': '传', '調': '调', '鬥': '斗', '變': '变', '歡': '欢',
+	'請': '请', '謝': '谢', '幣': '币', '銀': '银', '騙': '骗', '詐': '诈', '彈': '弹',
+}
+
+// SensitiveMatch 命中的敏感词，Start和End是原文中的字节位置，Text为原文中对应的内容（可能夹杂干扰字符）
+type SensitiveMatch struct {
+	Word  string `json:"word"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// WordFilter 基于Aho-Corasick自动机的本地敏感词过滤，不依赖数据服务，可以作为 CheckContent 的前置过滤或者熔断时的降级；
+// 匹配前统一全角半角、大小写和繁简体，并跳过夹在文字之间的干扰字符
+type WordFilter struct {
+	automaton atomic.Pointer[acAutomaton]
+	variants  map[rune]rune
+	noise     func(r rune) bool
+	maxNoise  int
+}
+
+const defaultWordMaxNoise = 3
+
+type WordFilterOption func(f *WordFilter)
+
+// WithWordVariants 补充异体字映射，比如繁体到简体
+func WithWordVariants(variants map[rune]rune) WordFilterOption {
+	return func(f *WordFilter) {
+		for k, v := range variants {
+			f.variants[k] = v
+		}
+	}
+}
+
+// WithWordNoise 判断干扰字符，匹配时会被跳过，默认除了文字和数字以外的字符都是干扰字符
+func WithWordNoise(noise func(r rune) bool) WordFilterOption {
+	return func(f *WordFilter) {
+		f.noise = noise
+	}
+}
+
+// WithWordMaxNoise 两个字之间最多跳过几个连续的干扰字符，超过时视为不相连，默认3个
+func WithWordMaxNoise(n int) WordFilterOption {
+	return func(f *WordFilter) {
+		f.maxNoise = n
+	}
+}
+
+func NewWordFilter(words []string, opts ...WordFilterOption) *WordFilter {
+	f := &WordFilter{variants: make(map[rune]rune, len(defaultWordVariants)), noise: isWordNoise,
+		maxNoise: defaultWordMaxNoise}
+	for k, v := range defaultWordVariants {
+		f.variants[k] = v
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	f.Reload(words)
+	return f
+}
+
+// Reload 重新构建词库，构建完成后原子地替换，不影响正在进行的匹配
+func (f *WordFilter) Reload(words []string) {
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		var runes []rune
+		for _, r := range w {
+			if r = f.normalize(r); !f.noise(r) {
+				runes = append(runes, r)
+			}
+		}
+		if len(runes) > 0 {
+			normalized = append(normalized, string(runes))
+		}
+	}
+	f.automaton.Store(newACAutomaton(normalized))
+}
+
+// ReloadFrom 从一行一个词的列表重新加载，忽略空行和#开头的注释
+func (f *WordFilter) ReloadFrom(r io.Reader) error {
+	words, err := readListLines(r)
+	if err != nil {
+		return err
+	}
+	f.Reload(words)
+	return nil
+}
+
+// Match 返回所有命中的敏感词，按结束位置排序，重叠的词都会返回
+func (f *WordFilter) Match(text string) []SensitiveMatch {
+	ac := f.automaton.Load()
+	if len(ac.words) == 0 {
+		return nil
+	}
+
+	var matches []SensitiveMatch
+	var starts []int // 规范化后的第i个字符在原文中的起始位置
+	state := int32(0)
+	noise := 0
+	for offset, r := range text {
+		if r = f.normalize(r); f.noise(r) {
+			if noise++; noise > f.maxNoise {
+				state = 0
+			}
+			continue
+		}
+		noise = 0
+		starts = append(starts, offset)
+		state = ac.step(state, r)
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		end := offset + size
+		for _, w := range ac.nodes[state].out {
+			start := starts[len(starts)-ac.lengths[w]]
+			matches = append(matches, SensitiveMatch{Word: ac.words[w], Text: text[start:end], Start: start, End: end})
+		}
+	}
+	return matches
+}
+
+// Contains 是否命中任意敏感词
+func (f *WordFilter) Contains(text string) bool {
+	return len(f.Match(text)) > 0
+}
+
+// Check 与 CheckContent 的参数和返回值相同，可以作为 WithCheckerFailOpen 的降级；
+// 返回值中 Pass 表示是否通过，Words 为命中的敏感词
+func (f *WordFilter) Check(ctx context.Context, req *httpproto.CheckContentReq) (*httpproto.CheckContentRsp, error) {
+	matches := f.Match(req.Content)
+	rsp := &httpproto.CheckContentRsp{Pass: len(matches) == 0}
+	for _, m := range matches {
+		rsp.Words = append(rsp.Words, m.Word)
+	}
+	return rsp, nil
+}
+
+// normalize 全角转半角、转小写、繁体转简体
+func (f *WordFilter) normalize(r rune) rune {
+	switch {
+	case r == '　':
+		r = ' '
+	case r >= '！' && r <= '～':
+		r -= 0xFEE0
+	}
+	r = unicode.ToLower(r)
+	if v, ok := f.variants[r]; ok {
+		r = v
+	}
+	return r
+}
+
+func isWordNoise(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// acAutomaton Aho-Corasick自动机，构建后只读
+type acAutomaton struct {
+	nodes   []acNode
+	words   []string
+	lengths []int // 每个词的字符数
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	out  []int // 以该节点结尾的词，包括通过失败指针可以到达的
+}
+
+func newACAutomaton(words []string) *acAutomaton {
+	ac := &acAutomaton{nodes: []acNode{{}}}
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		state := int32(0)
+		for _, r := range w {
+			next, ok := ac.nodes[state].next[r]
+			if !ok {
+				if ac.nodes[state].next == nil {
+					ac.nodes[state].next = make(map[rune]int32)
+				}
+				next = int32(len(ac.nodes))
+				ac.nodes[state].next[r] = next
+				ac.nodes = append(ac.nodes, acNode{})
+			}
+			state = next
+		}
+		ac.nodes[state].out = append(ac.nodes[state].out, len(ac.words))
+		ac.words = append(ac.words, w)
+		ac.lengths = append(ac.lengths, len([]rune(w)))
+	}
+
+	// 按层次遍历设置失败指针，子节点的输出合并失败指针指向节点的输出
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, child := range ac.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range ac.nodes[state].next {
+			fail := ac.nodes[state].fail
+			for {
+				if next, ok := ac.nodes[fail].next[r]; ok && next != child {
+					ac.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = ac.nodes[fail].fail
+			}
+			if f := ac.nodes[child].fail; len(ac.nodes[f].out) > 0 {
+				ac.nodes[child].out = append(ac.nodes[child].out, ac.nodes[f].out...)
+			}
+			queue = append(queue, child)
+		}
+	}
+	return ac
+}
+
+func (ac *acAutomaton) step(state int32, r rune) int32 {
+	for {
+		if next, ok := ac.nodes[state].next[r]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = ac.nodes[state].fail
+	}
+}
@@ -0,0 +1,71 @@
+package component
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nioliu/protocols/httpproto"
+)
+
+func TestWordFilterMatch(t *testing.T) {
+	f := NewWordFilter([]string{"赌博", "博彩", "fuck", "Ａbc", "he", "she", "his", "hers"})
+
+	tests := []struct {
+		text  string
+		words []string
+		texts []string
+	}{
+		{"正常的内容", nil, nil},
+		// 繁体和插入的干扰字符
+		{"來賭。博吧", []string{"赌博"}, []string{"賭。博"}},
+		// 全角、大小写和重叠的词
+		{"Ｆ*u*c*K abcd", []string{"fuck", "abc"}, []string{"Ｆ*u*c*K", "abc"}},
+		{"赌博彩", []string{"赌博", "博彩"}, []string{"赌博", "博彩"}},
+		{"ushers", []string{"she", "he", "hers"}, []string{"she", "he", "hers"}},
+		// 干扰字符太多时不算相连
+		{"赌....博", nil, nil},
+	}
+	for _, tt := range tests {
+		var words, texts []string
+		for _, m := range f.Match(tt.text) {
+			words = append(words, m.Word)
+			texts = append(texts, m.Text)
+			if tt.text[m.Start:m.End] != m.Text {
+				t.Fatalf("%q: bad offsets %+v", tt.text, m)
+			}
+		}
+		if !reflect.DeepEqual(words, tt.words) || !reflect.DeepEqual(texts, tt.texts) {
+			t.Fatalf("%q: expected %v %v, got %v %v", tt.text, tt.words, tt.texts, words, texts)
+		}
+	}
+}
+
+func TestWordFilterReload(t *testing.T) {
+	f := NewWordFilter(nil)
+	if f.Contains("赌博") {
+		t.Fatal("empty filter should not match")
+	}
+	if err := f.ReloadFrom(strings.NewReader("# 词库\n赌博\n\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Contains("赌 博") {
+		t.Fatal("expected match after reload")
+	}
+}
+
+func TestWordFilterCheck(t *testing.T) {
+	f := NewWordFilter([]string{"赌博", "博彩"})
+	rsp, err := f.Check(context.Background(), &httpproto.CheckContentReq{Content: "正常的内容"})
+	if err != nil || !rsp.Pass || len(rsp.Words) != 0 {
+		t.Fatalf("expected pass, got %+v %v", rsp, err)
+	}
+	rsp, err = f.Check(context.Background(), &httpproto.CheckContentReq{Content: "来赌博彩"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Pass || !reflect.DeepEqual(rsp.Words, []string{"赌博", "博彩"}) {
+		t.Fatalf("expected rejection with matched words, got %+v", rsp)
+	}
+}
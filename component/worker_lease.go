@@ -0,0 +1,302 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrNoFreeWorkerId  = errors.New("no free worker id in range")
+	ErrWorkerLeaseLost = errors.New("worker id lease lost")
+	ErrInvalidLeaseTTL = errors.New("invalid worker lease ttl")
+)
+
+const (
+	defaultWorkerLeaseTTL       = 30 * time.Second
+	defaultWorkerLeaseKeyPrefix = "snowflake:worker:"
+)
+
+// WorkerIdStore 机器ID租约的存储，抢占同一范围的所有实例必须使用同一个存储
+type WorkerIdStore interface {
+	// Acquire 键不存在时写入owner并设置ttl，返回是否抢占成功
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Renew 只有owner仍然持有该键时才续期，返回是否仍然持有
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release 只有owner仍然持有该键时才删除
+	Release(ctx context.Context, key, owner string) error
+}
+
+var (
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisWorkerIdStore 基于 SET NX PX 的租约存储，续期和释放通过脚本保证只操作自己持有的键
+type RedisWorkerIdStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisWorkerIdStore(client redis.Cmdable) *RedisWorkerIdStore {
+	return &RedisWorkerIdStore{client: client}
+}
+
+func (s *RedisWorkerIdStore) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, owner, ttl).Result()
+}
+
+func (s *RedisWorkerIdStore) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewLeaseScript.Run(ctx, s.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *RedisWorkerIdStore) Release(ctx context.Context, key, owner string) error {
+	return releaseLeaseScript.Run(ctx, s.client, []string{key}, owner).Err()
+}
+
+// MemoryWorkerIdStore 租约保存在map中，只能协调同一个进程内的多个生成器
+type MemoryWorkerIdStore struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+}
+
+type memoryLease struct {
+	owner    string
+	expireAt time.Time
+}
+
+func NewMemoryWorkerIdStore() *MemoryWorkerIdStore {
+	return &MemoryWorkerIdStore{leases: map[string]memoryLease{}}
+}
+
+func (s *MemoryWorkerIdStore) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.leases[key]; ok && time.Now().Before(l.expireAt) {
+		return false, nil
+	}
+	s.leases[key] = memoryLease{owner: owner, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryWorkerIdStore) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.leases[key]
+	if !ok || l.owner != owner || !time.Now().Before(l.expireAt) {
+		return false, nil
+	}
+	s.leases[key] = memoryLease{owner: owner, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryWorkerIdStore) Release(ctx context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.leases[key]; ok && l.owner == owner {
+		delete(s.leases, key)
+	}
+	return nil
+}
+
+// WorkerLease 从有限范围内抢占到的机器ID，后台心跳续期，续期失败或超过ttl未续上即视为丢失
+type WorkerLease struct {
+	store     WorkerIdStore
+	keyPrefix string
+	owner     string
+	ttl       time.Duration
+	interval  time.Duration
+
+	workerId int64
+	key      string
+	deadline atomic.Int64 // 租约有效期截止时间，UnixNano
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type WorkerLeaseOption func(l *WorkerLease)
+
+func applyWorkerLeaseOptions(l *WorkerLease, opts ...WorkerLeaseOption) {
+	for _, o := range opts {
+		o(l)
+	}
+}
+
+// WithLeaseTTL 租约过期时间，默认30s，不能小于1ms
+func WithLeaseTTL(ttl time.Duration) WorkerLeaseOption {
+	return func(l *WorkerLease) {
+		l.ttl = ttl
+	}
+}
+
+// WithHeartbeatInterval 续期间隔，默认为ttl的三分之一
+func WithHeartbeatInterval(interval time.Duration) WorkerLeaseOption {
+	return func(l *WorkerLease) {
+		l.interval = interval
+	}
+}
+
+// WithLeaseKeyPrefix 租约键前缀，默认 snowflake:worker:
+func WithLeaseKeyPrefix(prefix string) WorkerLeaseOption {
+	return func(l *WorkerLease) {
+		l.keyPrefix = prefix
+	}
+}
+
+// WithLeaseOwner 租约持有者标识，默认为 主机名:pid:随机数
+func WithLeaseOwner(owner string) WorkerLeaseOption {
+	return func(l *WorkerLease) {
+		l.owner = owner
+	}
+}
+
+// LeaseWorkerId 在[minId, maxId]内抢占一个空闲的机器ID，并启动心跳续期
+func LeaseWorkerId(ctx context.Context, store WorkerIdStore, minId, maxId int64,
+	opts ...WorkerLeaseOption) (*WorkerLease, error) {
+	if minId < 0 || maxId < minId {
+		return nil, fmt.Errorf("%w: invalid range [%d, %d]", ErrWorkerIdOutOfRange, minId, maxId)
+	}
+
+	l := &WorkerLease{
+		store:     store,
+		keyPrefix: defaultWorkerLeaseKeyPrefix,
+		ttl:       defaultWorkerLeaseTTL,
+		lost:      make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	applyWorkerLeaseOptions(l, opts...)
+	if l.owner == "" {
+		hostname, _ := os.Hostname()
+		l.owner = hostname + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(rand.Int63(), 36)
+	}
+	// Redis按毫秒设置过期时间，不足1ms时续期会变成 PEXPIRE 0，直接删除租约
+	if l.ttl < time.Millisecond {
+		return nil, fmt.Errorf("%w: %v is shorter than 1ms", ErrInvalidLeaseTTL, l.ttl)
+	}
+	if l.interval <= 0 || l.interval >= l.ttl {
+		l.interval = l.ttl / 3
+	}
+
+	// 从随机位置开始探测，减少多个实例同时启动时的冲突
+	size := maxId - minId + 1
+	offset := rand.Int63n(size)
+	for i := int64(0); i < size; i++ {
+		id := minId + (offset+i)%size
+		key := l.keyPrefix + strconv.FormatInt(id, 10)
+		start := time.Now()
+		ok, err := store.Acquire(ctx, key, l.owner, l.ttl)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			l.workerId = id
+			l.key = key
+			l.deadline.Store(start.Add(l.ttl).UnixNano())
+			go l.heartbeat()
+			return l, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: [%d, %d]", ErrNoFreeWorkerId, minId, maxId)
+}
+
+// WorkerId 抢占到的机器ID
+func (l *WorkerLease) WorkerId() int64 {
+	return l.workerId
+}
+
+// Valid 租约是否仍然有效
+func (l *WorkerLease) Valid() bool {
+	select {
+	case <-l.lost:
+		return false
+	default:
+	}
+	return time.Now().UnixNano() < l.deadline.Load()
+}
+
+// Lost 租约丢失时关闭
+func (l *WorkerLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Close 停止心跳并释放机器ID
+func (l *WorkerLease) Close(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.done
+	l.markLost()
+	return l.store.Release(ctx, l.key, l.owner)
+}
+
+func (l *WorkerLease) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
+
+func (l *WorkerLease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), l.interval)
+		ok, err := l.store.Renew(ctx, l.key, l.owner, l.ttl)
+		cancel()
+		switch {
+		case err == nil && ok:
+			l.deadline.Store(start.Add(l.ttl).UnixNano())
+		case err == nil && !ok:
+			// 已经被别人持有或者过期，不能再继续使用
+			l.markLost()
+			return
+		case time.Now().UnixNano() >= l.deadline.Load():
+			// 存储暂时不可用，超过ttl仍未续上
+			l.markLost()
+			return
+		}
+	}
+}
+
+// NewLeasedGenerator 使用租约中的机器ID创建生成器，租约丢失后生成器返回ErrWorkerLeaseLost
+func NewLeasedGenerator(lease *WorkerLease, opts ...GeneratorOption) (*Generator, error) {
+	g, err := NewGenerator(lease.WorkerId(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	g.lease = lease
+	return g, nil
+}
@@ -0,0 +1,160 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestRedis 每个测试单独启动一个miniredis，过期时间需要通过FastForward推进
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestLeaseWorkerId(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryWorkerIdStore()
+
+	leases := make([]*WorkerLease, 0, 4)
+	seen := map[int64]bool{}
+	for i := 0; i < 4; i++ {
+		l, err := LeaseWorkerId(ctx, store, 0, 3, WithLeaseTTL(time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[l.WorkerId()] {
+			t.Fatalf("worker id %d leased twice", l.WorkerId())
+		}
+		seen[l.WorkerId()] = true
+		leases = append(leases, l)
+		t.Cleanup(func() { l.Close(ctx) })
+	}
+
+	if _, err := LeaseWorkerId(ctx, store, 0, 3); !errors.Is(err, ErrNoFreeWorkerId) {
+		t.Fatalf("expected ErrNoFreeWorkerId, got %v", err)
+	}
+
+	// 释放之后可以被重新抢占
+	released := leases[0].WorkerId()
+	if err := leases[0].Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	l, err := LeaseWorkerId(ctx, store, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close(ctx) })
+	if l.WorkerId() != released {
+		t.Fatalf("expected released worker id %d, got %d", released, l.WorkerId())
+	}
+}
+
+func TestLeaseWorkerIdInvalidTTL(t *testing.T) {
+	store := NewMemoryWorkerIdStore()
+	for _, ttl := range []time.Duration{0, -time.Second, 2 * time.Nanosecond, 999 * time.Microsecond} {
+		if _, err := LeaseWorkerId(context.Background(), store, 0, 3, WithLeaseTTL(ttl)); !errors.Is(err, ErrInvalidLeaseTTL) {
+			t.Fatalf("ttl %v: expected ErrInvalidLeaseTTL, got %v", ttl, err)
+		}
+	}
+}
+
+func TestWorkerLeaseHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryWorkerIdStore()
+
+	l, err := LeaseWorkerId(ctx, store, 5, 5, WithLeaseTTL(200*time.Millisecond),
+		WithHeartbeatInterval(20*time.Millisecond), WithLeaseOwner("pod-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewLeasedGenerator(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 心跳续期，超过ttl后仍然有效
+	time.Sleep(500 * time.Millisecond)
+	if !l.Valid() {
+		t.Fatal("lease should still be valid")
+	}
+	if _, err = g.NextId(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟租约被别人删除
+	if err = store.Release(ctx, defaultWorkerLeaseKeyPrefix+"5", "pod-a"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease loss not detected")
+	}
+	if _, err = g.NextId(); !errors.Is(err, ErrWorkerLeaseLost) {
+		t.Fatalf("expected ErrWorkerLeaseLost, got %v", err)
+	}
+}
+
+func TestRedisWorkerIdStore(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestRedis(t)
+	store := NewRedisWorkerIdStore(client)
+
+	if ok, err := store.Acquire(ctx, "worker:1", "pod-a", time.Second); err != nil || !ok {
+		t.Fatalf("expected acquire, got %v %v", ok, err)
+	}
+	if ok, err := store.Acquire(ctx, "worker:1", "pod-b", time.Second); err != nil || ok {
+		t.Fatalf("expected conflict, got %v %v", ok, err)
+	}
+
+	// 只有持有者可以续期和释放
+	if ok, err := store.Renew(ctx, "worker:1", "pod-b", time.Second); err != nil || ok {
+		t.Fatalf("expected renew by other owner to fail, got %v %v", ok, err)
+	}
+	if ok, err := store.Renew(ctx, "worker:1", "pod-a", 5*time.Second); err != nil || !ok {
+		t.Fatalf("expected renew, got %v %v", ok, err)
+	}
+	if ttl := mr.TTL("worker:1"); ttl != 5*time.Second {
+		t.Fatalf("expected ttl 5s after renew, got %v", ttl)
+	}
+	if err := store.Release(ctx, "worker:1", "pod-b"); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("worker:1") {
+		t.Fatal("lease released by other owner")
+	}
+
+	// 过期后可以被别人抢占，原持有者不能再续期
+	mr.FastForward(5 * time.Second)
+	if ok, err := store.Acquire(ctx, "worker:1", "pod-b", time.Second); err != nil || !ok {
+		t.Fatalf("expected acquire after expiry, got %v %v", ok, err)
+	}
+	if ok, err := store.Renew(ctx, "worker:1", "pod-a", time.Second); err != nil || ok {
+		t.Fatalf("expected renew after expiry to fail, got %v %v", ok, err)
+	}
+	if err := store.Release(ctx, "worker:1", "pod-b"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("worker:1") {
+		t.Fatal("lease not released")
+	}
+
+	// 通过Redis抢占范围内的所有ID
+	for i := 0; i < 2; i++ {
+		l, err := LeaseWorkerId(ctx, store, 0, 1, WithLeaseTTL(time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close(ctx)
+	}
+	if _, err := LeaseWorkerId(ctx, store, 0, 1); !errors.Is(err, ErrNoFreeWorkerId) {
+		t.Fatalf("expected ErrNoFreeWorkerId, got %v", err)
+	}
+}
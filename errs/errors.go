@@ -3,6 +3,7 @@ package errs
 import (
 	"encoding/json"
 	"github.com/go-redis/redis/v8"
+	"time"
 )
 
 // Standard:
@@ -14,6 +15,7 @@ type ErrRsp struct {
 	Code        int    `json:"code,omitempty"`
 	Description string `json:"description,omitempty"`
 	Detail      string `json:"detail,omitempty"`
+	RetryAfter  int64  `json:"retry_after,omitempty"` // 限流时多少秒后可以重试
 }
 
 func (e *ErrRsp) Error() string {
@@ -36,6 +38,12 @@ func (e *ErrRsp) WithDetail(detail string) *ErrRsp {
 	return e
 }
 
+// WithRetryAfter retryAfter向上取整到秒
+func (e *ErrRsp) WithRetryAfter(retryAfter time.Duration) *ErrRsp {
+	e.RetryAfter = int64((retryAfter + time.Second - 1) / time.Second)
+	return e
+}
+
 func (e *ErrRsp) Err() *ErrRsp {
 	return e
 }
@@ -56,6 +64,57 @@ func GetDatabaseError(description string) *ErrRsp {
 	return &ErrRsp{Code: 1000, Description: description}
 }
 
+// 验证码相关的外部错误
+const (
+	VerifyCodeExpiredCode         = 2001
+	VerifyCodeMismatchCode        = 2002
+	VerifyCodeTooManyAttemptsCode = 2003
+	VerifyCodeResendTooSoonCode   = 2004
+)
+
+func GetVerifyCodeExpiredError() *ErrRsp {
+	return &ErrRsp{Code: VerifyCodeExpiredCode, Description: "verification code expired or not found"}
+}
+
+func GetVerifyCodeMismatchError() *ErrRsp {
+	return &ErrRsp{Code: VerifyCodeMismatchCode, Description: "verification code mismatch"}
+}
+
+func GetVerifyCodeTooManyAttemptsError() *ErrRsp {
+	return &ErrRsp{Code: VerifyCodeTooManyAttemptsCode, Description: "too many verification attempts"}
+}
+
+func GetVerifyCodeResendTooSoonError() *ErrRsp {
+	return &ErrRsp{Code: VerifyCodeResendTooSoonCode, Description: "verification code resent too soon"}
+}
+
+const TooManyRequestsCode = 2005
+
+// GetTooManyRequestsError retryAfter向上取整到秒
+func GetTooManyRequestsError(retryAfter time.Duration) *ErrRsp {
+	return (&ErrRsp{Code: TooManyRequestsCode, Description: "too many requests"}).WithRetryAfter(retryAfter)
+}
+
+// 通用的参数错误
+const (
+	InvalidArgumentCode  = 2000
+	MethodNotAllowedCode = 2006
+)
+
+func GetInvalidArgumentError() *ErrRsp {
+	return &ErrRsp{Code: InvalidArgumentCode, Description: "invalid argument"}
+}
+
+func GetMethodNotAllowedError() *ErrRsp {
+	return &ErrRsp{Code: MethodNotAllowedCode, Description: "method not allowed"}
+}
+
+const GenerateIdFailedCode = 1001
+
+func GetGenerateIdFailedError() *ErrRsp {
+	return &ErrRsp{Code: GenerateIdFailedCode, Description: "generate id failed"}
+}
+
 func NewError(code int, description string) *ErrRsp {
 	return &ErrRsp{Code: code, Description: description}
 }
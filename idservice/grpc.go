@@ -0,0 +1,6 @@
+package idservice
+
+// idservice.pb.go 和 idservice_grpc.pb.go 由 idservice.proto 生成，修改接口后重新执行 go generate
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative idservice.proto
+
+var _ IdServiceServer = (*Service)(nil)
@@ -0,0 +1,151 @@
+package idservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/nioliu/commons/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewHTTPHandler 路由如下，也可以POST对应请求消息的JSON；挂载在其它前缀下时配合 http.StripPrefix 使用
+//
+//	GET /next?parent_id=     单个ID
+//	GET /batch?count=        批量ID
+//	GET /decode?id=&format=  解析ID，format为 long、short、int64，不填时按内容猜测
+//
+// 成功时返回200和对应响应消息的JSON，字段名与.proto一致，int64字段按proto3的规则为字符串，
+// /decode 返回的 info.format 为 1长格式、2短格式、3 int64，time为RFC 3339；
+// 参数错误返回400，其它错误返回500，响应体为 errs.ErrRsp 的JSON
+func NewHTTPHandler(s *Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/next", s.httpHandler(IdService_NextId_FullMethodName,
+		func(q url.Values) (proto.Message, error) {
+			return &NextIdReq{ParentId: q.Get("parent_id")}, nil
+		},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.nextId(ctx, req.(*NextIdReq))
+		}))
+	mux.HandleFunc("/batch", s.httpHandler(IdService_NextBatch_FullMethodName,
+		func(q url.Values) (proto.Message, error) {
+			req := &NextBatchReq{}
+			if c := q.Get("count"); c != "" {
+				count, err := strconv.ParseInt(c, 10, 32)
+				if err != nil {
+					return nil, invalidArgument("invalid count " + strconv.Quote(c))
+				}
+				req.Count = int32(count)
+			}
+			return req, nil
+		},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.nextBatch(ctx, req.(*NextBatchReq))
+		}))
+	mux.HandleFunc("/decode", s.httpHandler(IdService_Decode_FullMethodName,
+		func(q url.Values) (proto.Message, error) {
+			format, ok := queryFormats[q.Get("format")]
+			if !ok {
+				return nil, invalidArgument("invalid format " + strconv.Quote(q.Get("format")))
+			}
+			return &DecodeReq{Id: q.Get("id"), Format: format}, nil
+		},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.decode(ctx, req.(*DecodeReq))
+		}))
+	return mux
+}
+
+const maxBodySize = 1 << 20
+
+var queryFormats = map[string]IdFormat{
+	"":      IdFormat_ID_FORMAT_UNSPECIFIED,
+	"long":  IdFormat_ID_FORMAT_LONG,
+	"short": IdFormat_ID_FORMAT_SHORT,
+	"int64": IdFormat_ID_FORMAT_INT64,
+}
+
+var (
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func (s *Service) httpHandler(method string, fromQuery func(q url.Values) (proto.Message, error),
+	call grpc.UnaryHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		traceId := requestTraceId(r)
+		ctx := context.WithValue(r.Context(), "trace_id", traceId)
+		w.Header().Set("X-Trace-Id", traceId)
+
+		var req proto.Message
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			req, err = fromQuery(r.URL.Query())
+		case http.MethodPost:
+			if req, err = fromQuery(url.Values{}); err == nil {
+				err = readBody(w, r, req)
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJson(w, http.StatusMethodNotAllowed, errs.GetMethodNotAllowedError())
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		rsp, err := s.invoke(ctx, method, req, call)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		body, err := marshalOptions.Marshal(rsp.(proto.Message))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// readBody 空请求体等同于 {}，超过 maxBodySize 时返回参数错误
+func readBody(w http.ResponseWriter, r *http.Request, req proto.Message) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return invalidArgument("read body: " + err.Error())
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if err = unmarshalOptions.Unmarshal(body, req); err != nil {
+		return invalidArgument("invalid json body: " + err.Error())
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var errRsp *errs.ErrRsp
+	if !errors.As(err, &errRsp) {
+		errRsp = errs.GetDefaultErrRsp().WithDetail(err.Error())
+	}
+	status := http.StatusInternalServerError
+	if isInvalidArgument(errRsp) {
+		status = http.StatusBadRequest
+	}
+	writeJson(w, status, errRsp)
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,682 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: idservice.proto
+
+package idservice
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// IdFormat 与 component.IdFormat 取值一致
+type IdFormat int32
+
+const (
+	IdFormat_ID_FORMAT_UNSPECIFIED IdFormat = 0
+	IdFormat_ID_FORMAT_LONG        IdFormat = 1 // CreateSnowflakeId / CreateSnowflakeIdV2
+	IdFormat_ID_FORMAT_SHORT       IdFormat = 2 // CreateShortSnowflakeId / CreateShortSnowflakeIdV2
+	IdFormat_ID_FORMAT_INT64       IdFormat = 3 // Generator
+)
+
+// Enum value maps for IdFormat.
+var (
+	IdFormat_name = map[int32]string{
+		0: "ID_FORMAT_UNSPECIFIED",
+		1: "ID_FORMAT_LONG",
+		2: "ID_FORMAT_SHORT",
+		3: "ID_FORMAT_INT64",
+	}
+	IdFormat_value = map[string]int32{
+		"ID_FORMAT_UNSPECIFIED": 0,
+		"ID_FORMAT_LONG":        1,
+		"ID_FORMAT_SHORT":       2,
+		"ID_FORMAT_INT64":       3,
+	}
+)
+
+func (x IdFormat) Enum() *IdFormat {
+	p := new(IdFormat)
+	*p = x
+	return p
+}
+
+func (x IdFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IdFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_idservice_proto_enumTypes[0].Descriptor()
+}
+
+func (IdFormat) Type() protoreflect.EnumType {
+	return &file_idservice_proto_enumTypes[0]
+}
+
+func (x IdFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IdFormat.Descriptor instead.
+func (IdFormat) EnumDescriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{0}
+}
+
+type NextIdReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ParentId string `protobuf:"bytes,1,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"` // 不为空时生成与它同一分片的ID
+}
+
+func (x *NextIdReq) Reset() {
+	*x = NextIdReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NextIdReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextIdReq) ProtoMessage() {}
+
+func (x *NextIdReq) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextIdReq.ProtoReflect.Descriptor instead.
+func (*NextIdReq) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NextIdReq) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+type NextIdRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *NextIdRsp) Reset() {
+	*x = NextIdRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NextIdRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextIdRsp) ProtoMessage() {}
+
+func (x *NextIdRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextIdRsp.ProtoReflect.Descriptor instead.
+func (*NextIdRsp) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NextIdRsp) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type NextBatchReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count int32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *NextBatchReq) Reset() {
+	*x = NextBatchReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NextBatchReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextBatchReq) ProtoMessage() {}
+
+func (x *NextBatchReq) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextBatchReq.ProtoReflect.Descriptor instead.
+func (*NextBatchReq) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *NextBatchReq) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type NextBatchRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ids []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+}
+
+func (x *NextBatchRsp) Reset() {
+	*x = NextBatchRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NextBatchRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextBatchRsp) ProtoMessage() {}
+
+func (x *NextBatchRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextBatchRsp.ProtoReflect.Descriptor instead.
+func (*NextBatchRsp) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *NextBatchRsp) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type DecodeReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Format IdFormat `protobuf:"varint,2,opt,name=format,proto3,enum=commons.idservice.IdFormat" json:"format,omitempty"` // 不填时按内容猜测，int64 ID碰巧符合短格式时会被误判，知道格式时应当指定
+}
+
+func (x *DecodeReq) Reset() {
+	*x = DecodeReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecodeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecodeReq) ProtoMessage() {}
+
+func (x *DecodeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecodeReq.ProtoReflect.Descriptor instead.
+func (*DecodeReq) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DecodeReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DecodeReq) GetFormat() IdFormat {
+	if x != nil {
+		return x.Format
+	}
+	return IdFormat_ID_FORMAT_UNSPECIFIED
+}
+
+type DecodeRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Info *IdInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+}
+
+func (x *DecodeRsp) Reset() {
+	*x = DecodeRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecodeRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecodeRsp) ProtoMessage() {}
+
+func (x *DecodeRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecodeRsp.ProtoReflect.Descriptor instead.
+func (*DecodeRsp) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DecodeRsp) GetInfo() *IdInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+type IdInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Format    IdFormat               `protobuf:"varint,1,opt,name=format,proto3,enum=commons.idservice.IdFormat" json:"format,omitempty"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`                            // 生成时间，短格式精确到秒
+	MachineId string                 `protobuf:"bytes,3,opt,name=machine_id,json=machineId,proto3" json:"machine_id,omitempty"` // 字符串格式中的机器ID原文
+	Shard     int64                  `protobuf:"varint,4,opt,name=shard,proto3" json:"shard,omitempty"`                         // int64格式中的分片号
+	WorkerId  int64                  `protobuf:"varint,5,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`   // int64格式中的机器ID
+	Sequence  int64                  `protobuf:"varint,6,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Mark      int32                  `protobuf:"varint,7,opt,name=mark,proto3" json:"mark,omitempty"` // 字符串格式末尾的标记位
+}
+
+func (x *IdInfo) Reset() {
+	*x = IdInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_idservice_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IdInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdInfo) ProtoMessage() {}
+
+func (x *IdInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_idservice_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdInfo.ProtoReflect.Descriptor instead.
+func (*IdInfo) Descriptor() ([]byte, []int) {
+	return file_idservice_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IdInfo) GetFormat() IdFormat {
+	if x != nil {
+		return x.Format
+	}
+	return IdFormat_ID_FORMAT_UNSPECIFIED
+}
+
+func (x *IdInfo) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *IdInfo) GetMachineId() string {
+	if x != nil {
+		return x.MachineId
+	}
+	return ""
+}
+
+func (x *IdInfo) GetShard() int64 {
+	if x != nil {
+		return x.Shard
+	}
+	return 0
+}
+
+func (x *IdInfo) GetWorkerId() int64 {
+	if x != nil {
+		return x.WorkerId
+	}
+	return 0
+}
+
+func (x *IdInfo) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *IdInfo) GetMark() int32 {
+	if x != nil {
+		return x.Mark
+	}
+	return 0
+}
+
+var File_idservice_proto protoreflect.FileDescriptor
+
+var file_idservice_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x11, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x28, 0x0a, 0x09, 0x4e, 0x65, 0x78, 0x74, 0x49, 0x64, 0x52,
+	0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22,
+	0x1b, 0x0a, 0x09, 0x4e, 0x65, 0x78, 0x74, 0x49, 0x64, 0x52, 0x73, 0x70, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x24, 0x0a, 0x0c,
+	0x4e, 0x65, 0x78, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x22, 0x20, 0x0a, 0x0c, 0x4e, 0x65, 0x78, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x73, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x03, 0x69, 0x64, 0x73, 0x22, 0x50, 0x0a, 0x09, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65,
+	0x71, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x33, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1b, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x49, 0x64, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x22, 0x3a, 0x0a, 0x09, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65,
+	0x52, 0x73, 0x70, 0x12, 0x2d, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x49, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x69, 0x6e,
+	0x66, 0x6f, 0x22, 0xef, 0x01, 0x0a, 0x06, 0x49, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x33, 0x0a,
+	0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e,
+	0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2e, 0x49, 0x64, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69,
+	0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x63, 0x68, 0x69, 0x6e, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x61, 0x63, 0x68, 0x69, 0x6e, 0x65, 0x49,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x61, 0x72, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x73, 0x68, 0x61, 0x72, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x77, 0x6f, 0x72, 0x6b, 0x65,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x77, 0x6f, 0x72, 0x6b,
+	0x65, 0x72, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x6d, 0x61, 0x72, 0x6b, 0x2a, 0x63, 0x0a, 0x08, 0x49, 0x64, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x19, 0x0a, 0x15, 0x49, 0x44, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x49,
+	0x44, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x4c, 0x4f, 0x4e, 0x47, 0x10, 0x01, 0x12,
+	0x13, 0x0a, 0x0f, 0x49, 0x44, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x5f, 0x53, 0x48, 0x4f,
+	0x52, 0x54, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x49, 0x44, 0x5f, 0x46, 0x4f, 0x52, 0x4d, 0x41,
+	0x54, 0x5f, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x10, 0x03, 0x32, 0xe6, 0x01, 0x0a, 0x09, 0x49, 0x64,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x44, 0x0a, 0x06, 0x4e, 0x65, 0x78, 0x74, 0x49,
+	0x64, 0x12, 0x1c, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4e, 0x65, 0x78, 0x74, 0x49, 0x64, 0x52, 0x65, 0x71, 0x1a,
+	0x1c, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x4e, 0x65, 0x78, 0x74, 0x49, 0x64, 0x52, 0x73, 0x70, 0x12, 0x4d, 0x0a,
+	0x09, 0x4e, 0x65, 0x78, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1f, 0x2e, 0x63, 0x6f, 0x6d,
+	0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4e,
+	0x65, 0x78, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x1a, 0x1f, 0x2e, 0x63, 0x6f,
+	0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x4e, 0x65, 0x78, 0x74, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x73, 0x70, 0x12, 0x44, 0x0a, 0x06,
+	0x44, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1c, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73,
+	0x2e, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x44, 0x65, 0x63, 0x6f, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x1a, 0x1c, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2e, 0x69,
+	0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x52,
+	0x73, 0x70, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x6e, 0x69, 0x6f, 0x6c, 0x69, 0x75, 0x2f, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x73, 0x2f,
+	0x69, 0x64, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x3b, 0x69, 0x64, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_idservice_proto_rawDescOnce sync.Once
+	file_idservice_proto_rawDescData = file_idservice_proto_rawDesc
+)
+
+func file_idservice_proto_rawDescGZIP() []byte {
+	file_idservice_proto_rawDescOnce.Do(func() {
+		file_idservice_proto_rawDescData = protoimpl.X.CompressGZIP(file_idservice_proto_rawDescData)
+	})
+	return file_idservice_proto_rawDescData
+}
+
+var file_idservice_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_idservice_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_idservice_proto_goTypes = []any{
+	(IdFormat)(0),                 // 0: commons.idservice.IdFormat
+	(*NextIdReq)(nil),             // 1: commons.idservice.NextIdReq
+	(*NextIdRsp)(nil),             // 2: commons.idservice.NextIdRsp
+	(*NextBatchReq)(nil),          // 3: commons.idservice.NextBatchReq
+	(*NextBatchRsp)(nil),          // 4: commons.idservice.NextBatchRsp
+	(*DecodeReq)(nil),             // 5: commons.idservice.DecodeReq
+	(*DecodeRsp)(nil),             // 6: commons.idservice.DecodeRsp
+	(*IdInfo)(nil),                // 7: commons.idservice.IdInfo
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_idservice_proto_depIdxs = []int32{
+	0, // 0: commons.idservice.DecodeReq.format:type_name -> commons.idservice.IdFormat
+	7, // 1: commons.idservice.DecodeRsp.info:type_name -> commons.idservice.IdInfo
+	0, // 2: commons.idservice.IdInfo.format:type_name -> commons.idservice.IdFormat
+	8, // 3: commons.idservice.IdInfo.time:type_name -> google.protobuf.Timestamp
+	1, // 4: commons.idservice.IdService.NextId:input_type -> commons.idservice.NextIdReq
+	3, // 5: commons.idservice.IdService.NextBatch:input_type -> commons.idservice.NextBatchReq
+	5, // 6: commons.idservice.IdService.Decode:input_type -> commons.idservice.DecodeReq
+	2, // 7: commons.idservice.IdService.NextId:output_type -> commons.idservice.NextIdRsp
+	4, // 8: commons.idservice.IdService.NextBatch:output_type -> commons.idservice.NextBatchRsp
+	6, // 9: commons.idservice.IdService.Decode:output_type -> commons.idservice.DecodeRsp
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_idservice_proto_init() }
+func file_idservice_proto_init() {
+	if File_idservice_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_idservice_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*NextIdReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*NextIdRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*NextBatchReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*NextBatchRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*DecodeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*DecodeRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_idservice_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*IdInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_idservice_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_idservice_proto_goTypes,
+		DependencyIndexes: file_idservice_proto_depIdxs,
+		EnumInfos:         file_idservice_proto_enumTypes,
+		MessageInfos:      file_idservice_proto_msgTypes,
+	}.Build()
+	File_idservice_proto = out.File
+	file_idservice_proto_rawDesc = nil
+	file_idservice_proto_goTypes = nil
+	file_idservice_proto_depIdxs = nil
+}
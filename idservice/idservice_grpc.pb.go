@@ -0,0 +1,183 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: idservice.proto
+
+package idservice
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	IdService_NextId_FullMethodName    = "/commons.idservice.IdService/NextId"
+	IdService_NextBatch_FullMethodName = "/commons.idservice.IdService/NextBatch"
+	IdService_Decode_FullMethodName    = "/commons.idservice.IdService/Decode"
+)
+
+// IdServiceClient is the client API for IdService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IdServiceClient interface {
+	NextId(ctx context.Context, in *NextIdReq, opts ...grpc.CallOption) (*NextIdRsp, error)
+	NextBatch(ctx context.Context, in *NextBatchReq, opts ...grpc.CallOption) (*NextBatchRsp, error)
+	Decode(ctx context.Context, in *DecodeReq, opts ...grpc.CallOption) (*DecodeRsp, error)
+}
+
+type idServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIdServiceClient(cc grpc.ClientConnInterface) IdServiceClient {
+	return &idServiceClient{cc}
+}
+
+func (c *idServiceClient) NextId(ctx context.Context, in *NextIdReq, opts ...grpc.CallOption) (*NextIdRsp, error) {
+	out := new(NextIdRsp)
+	err := c.cc.Invoke(ctx, IdService_NextId_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *idServiceClient) NextBatch(ctx context.Context, in *NextBatchReq, opts ...grpc.CallOption) (*NextBatchRsp, error) {
+	out := new(NextBatchRsp)
+	err := c.cc.Invoke(ctx, IdService_NextBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *idServiceClient) Decode(ctx context.Context, in *DecodeReq, opts ...grpc.CallOption) (*DecodeRsp, error) {
+	out := new(DecodeRsp)
+	err := c.cc.Invoke(ctx, IdService_Decode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IdServiceServer is the server API for IdService service.
+// All implementations must embed UnimplementedIdServiceServer
+// for forward compatibility
+type IdServiceServer interface {
+	NextId(context.Context, *NextIdReq) (*NextIdRsp, error)
+	NextBatch(context.Context, *NextBatchReq) (*NextBatchRsp, error)
+	Decode(context.Context, *DecodeReq) (*DecodeRsp, error)
+	mustEmbedUnimplementedIdServiceServer()
+}
+
+// UnimplementedIdServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedIdServiceServer struct {
+}
+
+func (UnimplementedIdServiceServer) NextId(context.Context, *NextIdReq) (*NextIdRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NextId not implemented")
+}
+func (UnimplementedIdServiceServer) NextBatch(context.Context, *NextBatchReq) (*NextBatchRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NextBatch not implemented")
+}
+func (UnimplementedIdServiceServer) Decode(context.Context, *DecodeReq) (*DecodeRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decode not implemented")
+}
+func (UnimplementedIdServiceServer) mustEmbedUnimplementedIdServiceServer() {}
+
+// UnsafeIdServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IdServiceServer will
+// result in compilation errors.
+type UnsafeIdServiceServer interface {
+	mustEmbedUnimplementedIdServiceServer()
+}
+
+func RegisterIdServiceServer(s grpc.ServiceRegistrar, srv IdServiceServer) {
+	s.RegisterService(&IdService_ServiceDesc, srv)
+}
+
+func _IdService_NextId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NextIdReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdServiceServer).NextId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdService_NextId_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdServiceServer).NextId(ctx, req.(*NextIdReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IdService_NextBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NextBatchReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdServiceServer).NextBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdService_NextBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdServiceServer).NextBatch(ctx, req.(*NextBatchReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IdService_Decode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecodeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdServiceServer).Decode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdService_Decode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdServiceServer).Decode(ctx, req.(*DecodeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IdService_ServiceDesc is the grpc.ServiceDesc for IdService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IdService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "commons.idservice.IdService",
+	HandlerType: (*IdServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NextId",
+			Handler:    _IdService_NextId_Handler,
+		},
+		{
+			MethodName: "NextBatch",
+			Handler:    _IdService_NextBatch_Handler,
+		},
+		{
+			MethodName: "Decode",
+			Handler:    _IdService_Decode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "idservice.proto",
+}
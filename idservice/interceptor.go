@@ -0,0 +1,75 @@
+package idservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/nioliu/commons/component"
+	"github.com/nioliu/commons/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 默认拦截器与 grpc/interceptor 中的 recover、调用日志一致；不引用该包，
+// 因为它在init()中加载权限配置，没有配置文件的服务引用后启动即panic
+
+// recoverInterceptor handler panic时记录调用栈并返回Internal
+func recoverInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 1024)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.ErrorWithCtxFields(ctx, fmt.Sprintf("[PANIC]%v\n%s\n", r, buf))
+			err = status.Error(codes.Internal, fmt.Sprint(r))
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// callLogInterceptor 记录请求、返回、耗时，metadata中的trace_id写入ctx
+func callLogInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	var remoteIp string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteIp = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if traceId := md.Get("trace_id"); len(traceId) > 0 {
+			ctx = context.WithValue(ctx, "trace_id", traceId[0])
+		}
+	}
+
+	before := time.Now()
+	rsp, err := handler(ctx, req)
+	reqBytes, _ := json.Marshal(req)
+	rspBytes, _ := json.Marshal(rsp)
+	errStr := ""
+	if err != nil {
+		errStr = err.Error()
+	}
+	log.InfoWithCtxFields(ctx, "call",
+		zap.ByteString("req", reqBytes),
+		zap.ByteString("rsp", rspBytes),
+		zap.String("remote_ip", remoteIp),
+		zap.String("full_method", info.FullMethod),
+		zap.String("error", errStr),
+		zap.String("duration", time.Since(before).String()))
+	return rsp, err
+}
+
+// requestTraceId 优先使用请求头中的 X-Trace-Id
+func requestTraceId(r *http.Request) string {
+	if id := r.Header.Get("X-Trace-Id"); id != "" {
+		return id
+	}
+	return component.CreateSnowflakeId("0")
+}
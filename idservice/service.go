@@ -0,0 +1,227 @@
+// Package idservice 通过gRPC和HTTP对外发放雪花ID，供不能直接引用component的Node、Python等服务使用。
+//
+// 接口定义在 idservice.proto 中，其它语言用它生成标准的gRPC stub即可调用；
+// 不方便使用gRPC时可以调用 NewHTTPHandler 提供的HTTP接口，请求和响应是同一组消息按proto3规则映射的JSON。
+package idservice
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/nioliu/commons/component"
+	"github.com/nioliu/commons/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultMaxBatch = 1000
+	maxClockSkew    = time.Minute // 允许调用方和本机的时钟偏差
+)
+
+// Service 持有共享的生成器，同时提供gRPC和HTTP两种入口
+type Service struct {
+	UnimplementedIdServiceServer
+
+	gen          *component.Generator
+	maxBatch     int
+	interceptors []grpc.UnaryServerInterceptor
+}
+
+type Option func(s *Service)
+
+// WithMaxBatch 单次批量获取的最大数量，默认1000
+func WithMaxBatch(n int) Option {
+	return func(s *Service) {
+		s.maxBatch = n
+	}
+}
+
+// WithInterceptors 替换默认的 recover、日志 拦截器
+func WithInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(s *Service) {
+		s.interceptors = interceptors
+	}
+}
+
+// NewService 默认已经带上 recover 和调用日志拦截器，注册到gRPC server时不需要再重复添加
+func NewService(gen *component.Generator, opts ...Option) *Service {
+	s := &Service{
+		gen:          gen,
+		maxBatch:     defaultMaxBatch,
+		interceptors: []grpc.UnaryServerInterceptor{recoverInterceptor, callLogInterceptor},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) NextId(ctx context.Context, req *NextIdReq) (*NextIdRsp, error) {
+	rsp, err := s.invoke(ctx, IdService_NextId_FullMethodName, req,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.nextId(ctx, req.(*NextIdReq))
+		})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rsp.(*NextIdRsp), nil
+}
+
+func (s *Service) NextBatch(ctx context.Context, req *NextBatchReq) (*NextBatchRsp, error) {
+	rsp, err := s.invoke(ctx, IdService_NextBatch_FullMethodName, req,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.nextBatch(ctx, req.(*NextBatchReq))
+		})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rsp.(*NextBatchRsp), nil
+}
+
+func (s *Service) Decode(ctx context.Context, req *DecodeReq) (*DecodeRsp, error) {
+	rsp, err := s.invoke(ctx, IdService_Decode_FullMethodName, req,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.decode(ctx, req.(*DecodeReq))
+		})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rsp.(*DecodeRsp), nil
+}
+
+func (s *Service) nextId(ctx context.Context, req *NextIdReq) (*NextIdRsp, error) {
+	var id int64
+	var err error
+	if req.ParentId != "" {
+		parentId, parseErr := strconv.ParseInt(req.ParentId, 10, 64)
+		if parseErr != nil {
+			return nil, invalidArgument("invalid parent id " + strconv.Quote(req.ParentId))
+		}
+		id, err = s.gen.NextIdColocated(parentId)
+	} else {
+		id, err = s.gen.NextId()
+	}
+	if err != nil {
+		return nil, generateFailed(err)
+	}
+	return &NextIdRsp{Id: strconv.FormatInt(id, 10)}, nil
+}
+
+func (s *Service) nextBatch(ctx context.Context, req *NextBatchReq) (*NextBatchRsp, error) {
+	if req.Count <= 0 || int(req.Count) > s.maxBatch {
+		return nil, invalidArgument("count must be in [1, " + strconv.Itoa(s.maxBatch) + "]")
+	}
+	ids, err := s.gen.NextN(int(req.Count))
+	if err != nil {
+		return nil, generateFailed(err)
+	}
+	rsp := &NextBatchRsp{Ids: make([]string, len(ids))}
+	for i, id := range ids {
+		rsp.Ids[i] = strconv.FormatInt(id, 10)
+	}
+	return rsp, nil
+}
+
+// decode int64格式按生成器的纪元和布局解析；没有指定格式时按内容猜测
+func (s *Service) decode(ctx context.Context, req *DecodeReq) (*DecodeRsp, error) {
+	var info *component.IdInfo
+	var err error
+	switch req.Format {
+	case IdFormat_ID_FORMAT_UNSPECIFIED:
+		info, err = s.guess(req.Id)
+	case IdFormat_ID_FORMAT_LONG:
+		info, err = component.ParseSnowflakeId(req.Id)
+	case IdFormat_ID_FORMAT_SHORT:
+		info, err = component.ParseShortSnowflakeId(req.Id)
+	case IdFormat_ID_FORMAT_INT64:
+		id, parseErr := strconv.ParseInt(req.Id, 10, 64)
+		if parseErr != nil {
+			return nil, invalidArgument("invalid int64 id " + strconv.Quote(req.Id))
+		}
+		info, err = s.gen.Decode(id)
+	default:
+		return nil, invalidArgument("unknown format " + req.Format.String())
+	}
+	if err != nil {
+		return nil, invalidArgument(err.Error())
+	}
+	return &DecodeRsp{Info: newIdInfo(info)}, nil
+}
+
+// guess 先识别短格式，再把纯数字当作int64，其它格式交给 component.ParseId；
+// 短格式ID也是纯数字并且可能在int64范围内，所以要先按时间前缀判断
+func (s *Service) guess(id string) (*component.IdInfo, error) {
+	if info, ok := parseShortId(id); ok {
+		return info, nil
+	}
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		return s.gen.Decode(n)
+	}
+	return component.ParseId(id)
+}
+
+// parseShortId 长度足够、以yyyyMMddHHmmss开头并且生成时间不晚于当前时间时才认为是短格式
+func parseShortId(id string) (*component.IdInfo, bool) {
+	info, err := component.ParseShortSnowflakeId(id)
+	if err != nil || info.Time.Year() < 2000 || info.Time.After(time.Now().Add(maxClockSkew)) {
+		return nil, false
+	}
+	return info, true
+}
+
+func newIdInfo(info *component.IdInfo) *IdInfo {
+	return &IdInfo{
+		Format:    IdFormat(info.Format),
+		Time:      timestamppb.New(info.Time),
+		MachineId: info.MachineId,
+		Shard:     info.Shard,
+		WorkerId:  info.WorkerId,
+		Sequence:  info.Sequence,
+		Mark:      int32(info.Mark),
+	}
+}
+
+// invoke 依次经过服务自带的拦截器再调用handler，gRPC和HTTP共用；注册到gRPC server时在server的拦截器之后执行
+func (s *Service) invoke(ctx context.Context, method string, req interface{},
+	handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{Server: s, FullMethod: method}
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		next, current := handler, s.interceptors[i]
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return current(ctx, req, info, next)
+		}
+	}
+	return handler(ctx, req)
+}
+
+func invalidArgument(detail string) *errs.ErrRsp {
+	return errs.GetInvalidArgumentError().WithDetail(detail)
+}
+
+func generateFailed(err error) *errs.ErrRsp {
+	return errs.GetGenerateIdFailedError().WithDetail(err.Error())
+}
+
+// grpcError 外部错误(2000 - 2999)对应 InvalidArgument，其它 ErrRsp 对应 Internal，
+// message为 ErrRsp 的JSON，调用方可以从中取出code；拦截器返回的status原样透传
+func grpcError(err error) error {
+	var errRsp *errs.ErrRsp
+	if !errors.As(err, &errRsp) {
+		return err
+	}
+	code := codes.Internal
+	if isInvalidArgument(errRsp) {
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, errRsp.Error())
+}
+
+func isInvalidArgument(err error) bool {
+	var errRsp *errs.ErrRsp
+	return errors.As(err, &errRsp) && errRsp.Code >= 2000 && errRsp.Code < 3000
+}
@@ -0,0 +1,214 @@
+package idservice
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nioliu/commons/component"
+	"github.com/nioliu/commons/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestService(t *testing.T) *Service {
+	layout := component.Layout{TimestampBits: 41, ShardBits: 4, WorkerBits: 6, SequenceBits: 12}
+	gen, err := component.NewGenerator(5, component.WithLayout(layout), component.WithShard(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(gen, WithMaxBatch(100))
+}
+
+func TestGrpcService(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterIdServiceServer(server, newTestService(t))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewIdServiceClient(conn)
+	ctx := context.Background()
+
+	single, err := client.NextId(ctx, &NextIdReq{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := client.NextBatch(ctx, &NextBatchReq{Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Ids) != 10 {
+		t.Fatalf("expected 10 ids, got %d", len(batch.Ids))
+	}
+	last, _ := strconv.ParseInt(single.Id, 10, 64)
+	for _, s := range batch.Ids {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= last {
+			t.Fatalf("id %s not greater than previous %d", s, last)
+		}
+		last = id
+	}
+
+	decoded, err := client.Decode(ctx, &DecodeReq{Id: single.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Info.WorkerId != 5 || decoded.Info.Shard != 3 {
+		t.Fatalf("unexpected decode result %+v", decoded.Info)
+	}
+
+	// 纯数字的短格式ID不能被当成int64解析
+	short, err := component.CreateShortSnowflakeIdV2("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = strconv.ParseInt(short, 10, 64); err != nil {
+		t.Fatalf("expected %s to fit int64", short)
+	}
+	decoded, err = client.Decode(ctx, &DecodeReq{Id: short})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Info.Format != IdFormat_ID_FORMAT_SHORT || decoded.Info.MachineId != "7" ||
+		time.Since(decoded.Info.Time.AsTime()) > time.Minute {
+		t.Fatalf("unexpected decode result %+v", decoded.Info)
+	}
+
+	if _, err = client.NextBatch(ctx, &NextBatchReq{Count: 101}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for oversized batch, got %v", err)
+	}
+}
+
+func TestDecodeFormat(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	// 真实的int64 ID，但前14位恰好是合法的yyyyMMddHHmmss
+	const id = "2020010112000000010"
+	if _, ok := parseShortId(id); !ok {
+		t.Fatalf("expected %s to look like a short id", id)
+	}
+
+	guessed, err := s.Decode(ctx, &DecodeReq{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guessed.Info.Format != IdFormat_ID_FORMAT_SHORT {
+		t.Fatalf("expected guess to be short, got %v", guessed.Info.Format)
+	}
+
+	decoded, err := s.Decode(ctx, &DecodeReq{Id: id, Format: IdFormat_ID_FORMAT_INT64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := s.gen.Decode(2020010112000000010)
+	if decoded.Info.Format != IdFormat_ID_FORMAT_INT64 || decoded.Info.WorkerId != expected.WorkerId ||
+		decoded.Info.Shard != expected.Shard || decoded.Info.Sequence != expected.Sequence {
+		t.Fatalf("unexpected decode result %+v, expected %+v", decoded.Info, expected)
+	}
+
+	if _, err = s.Decode(ctx, &DecodeReq{Id: "abc", Format: IdFormat_ID_FORMAT_INT64}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	server := httptest.NewServer(NewHTTPHandler(newTestService(t)))
+	defer server.Close()
+
+	var next NextIdRsp
+	get(t, server.URL+"/next", http.StatusOK, &next)
+	var batch NextBatchRsp
+	get(t, server.URL+"/batch?count=3", http.StatusOK, &batch)
+	if len(batch.Ids) != 3 {
+		t.Fatalf("expected 3 ids, got %v", batch.Ids)
+	}
+
+	var colocated NextIdRsp
+	rsp, err := http.Post(server.URL+"/next", "application/json",
+		strings.NewReader(`{"parent_id":"`+next.Id+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	body, _ := io.ReadAll(rsp.Body)
+	if err = protojson.Unmarshal(body, &colocated); err != nil || colocated.Id == "" {
+		t.Fatalf("unexpected response %s, %v", body, err)
+	}
+
+	rsp, err = http.Post(server.URL+"/next", "application/json",
+		strings.NewReader(`{"parent_id":"`+strings.Repeat(" ", maxBodySize)+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for oversized body, got %d", rsp.StatusCode)
+	}
+
+	var decoded DecodeRsp
+	get(t, server.URL+"/decode?id="+component.CreateSnowflakeId("0118"), http.StatusOK, &decoded)
+	if decoded.Info.Format != IdFormat_ID_FORMAT_LONG || decoded.Info.MachineId != "0118" {
+		t.Fatalf("unexpected decode result %+v", decoded.Info)
+	}
+
+	get(t, server.URL+"/decode?id=2020010112000000010&format=int64", http.StatusOK, &decoded)
+	if decoded.Info.Format != IdFormat_ID_FORMAT_INT64 {
+		t.Fatalf("unexpected decode result %+v", decoded.Info)
+	}
+
+	get(t, server.URL+"/decode?id=1&format=hex", http.StatusBadRequest, nil)
+	get(t, server.URL+"/batch?count=abc", http.StatusBadRequest, nil)
+	get(t, server.URL+"/decode?id=x", http.StatusBadRequest, nil)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/next", nil)
+	rsp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	var errRsp errs.ErrRsp
+	if err = json.NewDecoder(rsp.Body).Decode(&errRsp); err != nil || rsp.StatusCode != http.StatusMethodNotAllowed ||
+		errRsp.Code != errs.MethodNotAllowedCode {
+		t.Fatalf("unexpected response %d %+v, %v", rsp.StatusCode, errRsp, err)
+	}
+}
+
+func get(t *testing.T, url string, status int, v proto.Message) {
+	t.Helper()
+	rsp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != status {
+		t.Fatalf("GET %s: status %d, expected %d", url, rsp.StatusCode, status)
+	}
+	body, _ := io.ReadAll(rsp.Body)
+	if v != nil {
+		if err = protojson.Unmarshal(body, v); err != nil {
+			t.Fatalf("GET %s: %v, body %s", url, err, body)
+		}
+	}
+}